@@ -0,0 +1,78 @@
+package algebrain
+
+import (
+	"math"
+
+	"github.com/unixpickle/anydiff"
+	"github.com/unixpickle/anyvec"
+)
+
+// QueryAttention runs a query like Query, but also returns
+// the attention weights used to produce the response.
+//
+// The i-th row of the weights corresponds to the i-th
+// character of the response.
+// The j-th entry of a row is the amount of attention paid
+// to the j-th character of the query, and each row sums
+// to 1.
+func (n *Network) QueryAttention(q string) (string, [][]float64, error) {
+	d, err := n.decoder(q)
+	if err != nil {
+		return "", nil, err
+	}
+	state := d.Start()
+
+	var lastChar rune
+	var res string
+	var weights [][]float64
+
+	for len(res) < maxResponseLen {
+		var logProbs, attention []float64
+		state, logProbs, attention = d.Step(state, lastChar)
+		lastChar = rune(maxIndex(logProbs))
+		if lastChar == Terminator {
+			break
+		}
+		res += string(lastChar)
+		weights = append(weights, attention)
+	}
+
+	return res, weights, nil
+}
+
+// attentionWeights computes the distribution over the
+// encoded query which the SoftAlign block attends to when
+// given a decoder query vector.
+func (n *Network) attentionWeights(query anyvec.Vector, enc []anyvec.Vector) []float64 {
+	if len(enc) == 0 {
+		return []float64{}
+	}
+	c := n.creator()
+	queries := make([]anyvec.Vector, len(enc))
+	for i := range queries {
+		queries[i] = query
+	}
+	logits := n.Align.Attentor.Mix(
+		anydiff.NewConst(c.Concat(queries...)),
+		anydiff.NewConst(c.Concat(enc...)),
+		len(enc),
+	).Output()
+	return softmax(vectorFloats(logits))
+}
+
+func softmax(logits []float64) []float64 {
+	maxLogit := math.Inf(-1)
+	for _, x := range logits {
+		maxLogit = math.Max(maxLogit, x)
+	}
+	res := make([]float64, len(logits))
+	var total float64
+	for i, x := range logits {
+		res[i] = math.Exp(x - maxLogit)
+		total += res[i]
+	}
+	for i := range res {
+		res[i] /= total
+	}
+	return res
+}
@@ -0,0 +1,129 @@
+package algebrain
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/unixpickle/anyvec"
+)
+
+// A Candidate is a response produced by a decoder.
+type Candidate struct {
+	Response string
+
+	// LogProb is the total log probability of the response,
+	// including the terminator if it was produced.
+	LogProb float64
+}
+
+// QueryBeam runs a query using beam search.
+//
+// The result contains at most width complete responses,
+// sorted from most to least likely.
+// The width must be at least 1.
+func (n *Network) QueryBeam(q string, width int) ([]*Candidate, error) {
+	if width < 1 {
+		return nil, fmt.Errorf("query beam: invalid width %d", width)
+	}
+	d, err := n.decoder(q)
+	if err != nil {
+		return nil, err
+	}
+	beam := []*beamEntry{{state: d.Start()}}
+	var finished []*Candidate
+
+	for len(beam) > 0 {
+		var next []*beamEntry
+		for _, entry := range beam {
+			if len(entry.response) >= maxResponseLen {
+				finished = append(finished, entry.candidate())
+				continue
+			}
+			state, logProbs, _ := d.Step(entry.state, entry.last)
+			for _, idx := range topIndices(logProbs, width) {
+				child := &beamEntry{
+					state:    state,
+					response: entry.response,
+					last:     rune(idx),
+					logProb:  entry.logProb + logProbs[idx],
+				}
+				if child.last == Terminator {
+					finished = append(finished, child.candidate())
+				} else {
+					child.response += string(child.last)
+					next = append(next, child)
+				}
+			}
+		}
+		sort.Slice(next, func(i, j int) bool {
+			return next[i].logProb > next[j].logProb
+		})
+		if len(next) > width {
+			next = next[:width]
+		}
+		beam = next
+
+		// Log probabilities only decrease as responses grow,
+		// so we can stop once no live hypothesis can beat the
+		// finished ones.
+		sortCandidates(finished)
+		if len(finished) >= width && len(beam) > 0 &&
+			beam[0].logProb < finished[width-1].LogProb {
+			break
+		}
+	}
+
+	sortCandidates(finished)
+	if len(finished) > width {
+		finished = finished[:width]
+	}
+	return finished, nil
+}
+
+type beamEntry struct {
+	state    *decoderState
+	response string
+	last     rune
+	logProb  float64
+}
+
+func (b *beamEntry) candidate() *Candidate {
+	return &Candidate{Response: b.response, LogProb: b.logProb}
+}
+
+func sortCandidates(c []*Candidate) {
+	sort.SliceStable(c, func(i, j int) bool {
+		return c[i].LogProb > c[j].LogProb
+	})
+}
+
+// topIndices returns the indices of the k largest values,
+// sorted in descending order of value.
+func topIndices(values []float64, k int) []int {
+	indices := make([]int, len(values))
+	for i := range indices {
+		indices[i] = i
+	}
+	sort.SliceStable(indices, func(i, j int) bool {
+		return values[indices[i]] > values[indices[j]]
+	})
+	if len(indices) > k {
+		indices = indices[:k]
+	}
+	return indices
+}
+
+// maxIndex returns the index of the largest value.
+func maxIndex(values []float64) int {
+	var res int
+	for i, x := range values {
+		if x > values[res] {
+			res = i
+		}
+	}
+	return res
+}
+
+func vectorFloats(v anyvec.Vector) []float64 {
+	return v.Creator().Float64Slice(v.Data())
+}
@@ -0,0 +1,146 @@
+package algebrain
+
+import (
+	"math"
+	"math/rand"
+	"strings"
+
+	"github.com/unixpickle/algebrain/mathexpr"
+)
+
+const (
+	DefaultCheckerTrials    = 10
+	DefaultCheckerTolerance = 1e-4
+	DefaultCheckerRange     = 5
+)
+
+// A Verdict describes how a response compares to the
+// expected response of a Sample.
+type Verdict struct {
+	// Exact is set if the two responses are identical.
+	Exact bool
+
+	// Structural is set if the two responses parse to the
+	// same expression tree.
+	Structural bool
+
+	// Numeric is set if the two responses evaluate to the
+	// same values at random points.
+	Numeric bool
+}
+
+// A Checker decides whether responses are correct.
+type Checker struct {
+	// Trials is the number of random variable assignments
+	// used to test for numeric equivalence.
+	// If 0, DefaultCheckerTrials is used.
+	Trials int
+
+	// Tolerance is the maximum relative difference between
+	// two values which are considered equal.
+	// If 0, DefaultCheckerTolerance is used.
+	Tolerance float64
+
+	// Range is the maximum absolute value of a variable in
+	// a random assignment.
+	// If 0, DefaultCheckerRange is used.
+	Range float64
+
+	// Rand is used to generate variable assignments.
+	// If nil, the math/rand package is used.
+	Rand *rand.Rand
+}
+
+// Check compares a response to the sample's expected
+// response.
+func (c *Checker) Check(s *Sample, response string) *Verdict {
+	res := &Verdict{Exact: response == s.Response}
+	if res.Exact {
+		res.Structural = true
+		res.Numeric = true
+		return res
+	}
+	expected, err := ParseResponse(s.Response)
+	if err != nil {
+		return res
+	}
+	actual, err := ParseResponse(response)
+	if err != nil {
+		return res
+	}
+	if strings.HasPrefix(s.Response, ResultPrefix) !=
+		strings.HasPrefix(response, ResultPrefix) {
+		return res
+	}
+	res.Structural = mathexpr.Equal(expected, actual)
+	res.Numeric = res.Structural || c.NumericMatch(expected, actual)
+	return res
+}
+
+// NumericMatch checks if two expressions evaluate to the
+// same values at random points.
+//
+// Points where expected cannot be evaluated are skipped.
+// If no point could be evaluated, false is returned.
+func (c *Checker) NumericMatch(expected, actual mathexpr.Node) bool {
+	vars := mathexpr.Variables(expected)
+	vars = append(vars, mathexpr.Variables(actual)...)
+
+	var numChecked int
+	for i := 0; i < c.trials()*4 && numChecked < c.trials(); i++ {
+		env := map[string]float64{}
+		for _, v := range vars {
+			env[v] = (c.float64()*2 - 1) * c.varRange()
+		}
+		expectedVal, err := mathexpr.Eval(expected, env)
+		if _, ok := err.(*mathexpr.DomainError); ok {
+			continue
+		} else if err != nil {
+			return false
+		}
+		actualVal, err := mathexpr.Eval(actual, env)
+		if err != nil {
+			return false
+		}
+		scale := math.Max(1, math.Max(math.Abs(expectedVal), math.Abs(actualVal)))
+		if math.Abs(expectedVal-actualVal) > c.tolerance()*scale {
+			return false
+		}
+		numChecked++
+	}
+	return numChecked > 0
+}
+
+func (c *Checker) trials() int {
+	if c.Trials == 0 {
+		return DefaultCheckerTrials
+	}
+	return c.Trials
+}
+
+func (c *Checker) tolerance() float64 {
+	if c.Tolerance == 0 {
+		return DefaultCheckerTolerance
+	}
+	return c.Tolerance
+}
+
+func (c *Checker) varRange() float64 {
+	if c.Range == 0 {
+		return DefaultCheckerRange
+	}
+	return c.Range
+}
+
+func (c *Checker) float64() float64 {
+	if c.Rand == nil {
+		return rand.Float64()
+	}
+	return c.Rand.Float64()
+}
+
+// ParseResponse parses a response as an expression.
+// A leading ResultPrefix is ignored.
+func ParseResponse(response string) (mathexpr.Node, error) {
+	return mathexpr.Parse(strings.TrimPrefix(response, ResultPrefix))
+}
@@ -0,0 +1,73 @@
+package algebrain
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/unixpickle/algebrain/mathexpr"
+)
+
+func TestCheckerCheck(t *testing.T) {
+	checker := &Checker{Rand: rand.New(rand.NewSource(1337))}
+	sample := &Sample{Query: "scale x by 2 in x+1", Response: "x*2+1"}
+	cases := []struct {
+		Response string
+		Expected Verdict
+	}{
+		{"x*2+1", Verdict{Exact: true, Structural: true, Numeric: true}},
+		{"x*2 + 1", Verdict{Structural: true, Numeric: true}},
+		{"2*x+1", Verdict{Numeric: true}},
+		{"1+x+x", Verdict{Numeric: true}},
+		{"x*3+1", Verdict{}},
+		{"x*2+", Verdict{}},
+		{ResultPrefix + "x*2+1", Verdict{}},
+	}
+	for _, c := range cases {
+		actual := checker.Check(sample, c.Response)
+		if *actual != c.Expected {
+			t.Errorf("response %q: expected %+v got %+v", c.Response, c.Expected, *actual)
+		}
+	}
+
+	evalSample := &Sample{Query: "evaluate 1+2", Response: ResultPrefix + "3"}
+	if v := checker.Check(evalSample, "3"); v.Structural || v.Numeric {
+		t.Errorf("missing result prefix should not match: %+v", *v)
+	}
+	if v := checker.Check(evalSample, ResultPrefix+"3.0"); v.Exact || !v.Numeric {
+		t.Errorf("unexpected verdict for equivalent result: %+v", *v)
+	}
+}
+
+func TestCheckerNumericMatch(t *testing.T) {
+	checker := &Checker{Rand: rand.New(rand.NewSource(1337))}
+	parse := func(s string) mathexpr.Node {
+		res, err := mathexpr.Parse(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return res
+	}
+
+	if !checker.NumericMatch(parse("(x-1)^2"), parse("x^2-2*x+1")) {
+		t.Error("expected equivalent expressions to match")
+	}
+	if checker.NumericMatch(parse("x^2"), parse("x^3")) {
+		t.Error("expected different expressions not to match")
+	}
+
+	// Points where only the expected expression fails are
+	// skipped, so ln(x^2) and 2*ln(x) disagree only where x
+	// is negative and the actual expression fails.
+	if checker.NumericMatch(parse("ln(x^2)"), parse("2*ln(x)")) {
+		t.Error("expected failure of the actual expression to prevent a match")
+	}
+	if !checker.NumericMatch(parse("ln(x)"), parse("ln(x)+0*y")) {
+		t.Error("expected match where the expected expression is defined")
+	}
+
+	// If the expected expression can never be evaluated,
+	// nothing can be checked.
+	if checker.NumericMatch(parse("1/(x-x)"), parse("1/(x-x)")) {
+		t.Error("expected no match without any valid points")
+	}
+}
@@ -0,0 +1,114 @@
+package algebrain
+
+import (
+	"github.com/unixpickle/anydiff"
+	"github.com/unixpickle/anydiff/anyseq"
+	"github.com/unixpickle/anynet/anyrnn"
+	"github.com/unixpickle/anyvec"
+)
+
+// queryEach greedily decodes each query separately.
+//
+// This is used for pointer-generator networks, since the
+// copy distribution depends on each query's own attention.
+func (n *Network) queryEach(qs []string) ([]string, error) {
+	res := make([]string, len(qs))
+	for i, q := range qs {
+		d, err := n.decoder(q)
+		if err != nil {
+			return nil, err
+		}
+		state := d.Start()
+		var lastChar rune
+		for len(res[i]) < maxResponseLen {
+			var logProbs []float64
+			state, logProbs, _ = d.Step(state, lastChar)
+			lastChar = rune(maxIndex(logProbs))
+			if lastChar == Terminator {
+				break
+			}
+			res[i] += string(lastChar)
+		}
+	}
+	return res, nil
+}
+
+// copyLogProbs is LogProbs for pointer-generator networks.
+func (n *Network) copyLogProbs(encIn, decIn anyseq.Seq) anyseq.Seq {
+	inSeqs := splitSeq(encIn)
+	decSeqs := splitSeq(decIn)
+	outs := make([]anydiff.Res, len(decSeqs))
+	lens := make([]int, len(decSeqs))
+	for i, decSeq := range decSeqs {
+		outs[i] = n.copyLogProbsSingle(inSeqs[i], decSeq)
+		lens[i] = len(decSeq)
+	}
+	return packSeqs(encIn.Creator(), outs, lens, CharCount)
+}
+
+// copyLogProbsSingle computes the output log probabilities
+// for a single teacher-forced sequence, mixing generation
+// and copying with the copy gate.
+func (n *Network) copyLogProbsSingle(inSeq, decSeq []anyvec.Vector) anydiff.Res {
+	c := n.creator()
+	inLen, outLen := len(inSeq), len(decSeq)
+	encSize := n.Config.EncodedSize
+	querySize := n.Config.QuerySize
+
+	enc := n.Encoder.Apply(anyseq.ConstSeqList(c, [][]anyvec.Vector{inSeq}))
+	return anydiff.Pool(seqToRes(enc), func(encMat anydiff.Res) anydiff.Res {
+		encSeq := packSeqs(c, []anydiff.Res{encMat}, []int{inLen}, encSize)
+		decInSeq := anyseq.ConstSeqList(c, [][]anyvec.Vector{decSeq})
+		queries := seqToRes(anyrnn.Map(decInSeq, n.Align.Block(encSeq)))
+		return anydiff.Pool(queries, func(queries anydiff.Res) anydiff.Res {
+			genProbs := anydiff.Exp(n.Output.Apply(queries, outLen))
+
+			// The attention at each step uses the previous
+			// step's decoder output as its query.
+			prevQueries := anydiff.Concat(
+				n.Align.InitQuery,
+				anydiff.Slice(queries, 0, (outLen-1)*querySize),
+			)
+			attention := n.copyAttention(prevQueries, outLen, encMat, inLen)
+			copyProbs := matMul(false, false, attention, outLen, inLen,
+				anydiff.NewConst(c.Concat(inSeq...)), inLen, CharCount)
+
+			gate := matMul(false, false, n.CopyGate.Apply(queries, outLen), outLen, 1,
+				filledConst(c, CharCount, 1), 1, CharCount)
+			invGate := anydiff.AddScalar(anydiff.Scale(gate, c.MakeNumeric(-1)),
+				c.MakeNumeric(1))
+			mixed := anydiff.Add(anydiff.Mul(gate, genProbs), anydiff.Mul(invGate, copyProbs))
+			return anydiff.Log(anydiff.AddScalar(mixed, c.MakeNumeric(1e-10)))
+		})
+	})
+}
+
+// copyAttention computes the attention weights for every
+// pair of query and encoded vector, producing a row of
+// weights for each query.
+func (n *Network) copyAttention(queries anydiff.Res, numQueries int, enc anydiff.Res,
+	encLen int) anydiff.Res {
+	c := n.creator()
+	numPairs := numQueries * encLen
+
+	// Selection matrices which repeat each query and tile
+	// the encoded vectors so that every pair is a row.
+	querySel := make([]float64, numPairs*numQueries)
+	encSel := make([]float64, numPairs*encLen)
+	for i := 0; i < numQueries; i++ {
+		for j := 0; j < encLen; j++ {
+			row := i*encLen + j
+			querySel[row*numQueries+i] = 1
+			encSel[row*encLen+j] = 1
+		}
+	}
+	pairQueries := matMul(false, false,
+		anydiff.NewConst(c.MakeVectorData(c.MakeNumericList(querySel))), numPairs, numQueries,
+		queries, numQueries, n.Config.QuerySize)
+	pairEnc := matMul(false, false,
+		anydiff.NewConst(c.MakeVectorData(c.MakeNumericList(encSel))), numPairs, encLen,
+		enc, encLen, n.Config.EncodedSize)
+
+	logits := n.Align.Attentor.Mix(pairQueries, pairEnc, numPairs)
+	return anydiff.Exp(anydiff.LogSoftmax(logits, encLen))
+}
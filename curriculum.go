@@ -0,0 +1,120 @@
+package algebrain
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/unixpickle/essentials"
+)
+
+// DefaultCurriculumThreshold is the validation accuracy
+// needed to leave each tier of DefaultCurriculum.
+const DefaultCurriculumThreshold = 0.9
+
+// A CurriculumTier is one stage of a Curriculum.
+type CurriculumTier struct {
+	// Generators are names of entries in Generators.
+	Generators []string
+
+	// Threshold is the validation accuracy on this tier's
+	// generators which is needed to move to the next tier.
+	Threshold float64
+}
+
+// A Curriculum trains on progressively harder generators.
+//
+// While on a tier, training uses the generators of that
+// tier and every tier before it, so that earlier tasks
+// are not forgotten.
+type Curriculum struct {
+	Tiers []*CurriculumTier
+
+	// Tier is the index of the current tier.
+	Tier int
+}
+
+// DefaultCurriculum creates a Curriculum over the Easy,
+// Medium, and Hard entries of DefaultGeneratorNames.
+func DefaultCurriculum() *Curriculum {
+	res := &Curriculum{}
+	for _, level := range []string{"Easy", "Medium", "Hard"} {
+		tier := &CurriculumTier{Threshold: DefaultCurriculumThreshold}
+		for _, name := range DefaultGeneratorNames {
+			if strings.HasPrefix(name, level) {
+				tier.Generators = append(tier.Generators, name)
+			}
+		}
+		res.Tiers = append(res.Tiers, tier)
+	}
+	return res
+}
+
+// ParseCurriculum parses a Curriculum from a string like
+// "EasyShift,EasyScale:0.9;HardShift".
+//
+// Tiers are separated by semicolons, and each tier lists
+// its generators followed by an optional threshold.
+// The threshold defaults to DefaultCurriculumThreshold.
+func ParseCurriculum(s string) (*Curriculum, error) {
+	res := &Curriculum{}
+	for _, tierStr := range strings.Split(s, ";") {
+		tier := &CurriculumTier{Threshold: DefaultCurriculumThreshold}
+		if idx := strings.LastIndex(tierStr, ":"); idx >= 0 {
+			threshold, err := strconv.ParseFloat(tierStr[idx+1:], 64)
+			if err != nil {
+				return nil, essentials.AddCtx("parse curriculum", err)
+			}
+			tier.Threshold = threshold
+			tierStr = tierStr[:idx]
+		}
+		tier.Generators = strings.Split(tierStr, ",")
+		if _, err := NamedGenerators(tier.Generators); err != nil {
+			return nil, essentials.AddCtx("parse curriculum", err)
+		}
+		res.Tiers = append(res.Tiers, tier)
+	}
+	return res, nil
+}
+
+// Current returns the current tier.
+func (c *Curriculum) Current() *CurriculumTier {
+	return c.Tiers[c.Tier]
+}
+
+// Done returns true if the curriculum is on its last tier.
+func (c *Curriculum) Done() bool {
+	return c.Tier == len(c.Tiers)-1
+}
+
+// Generators returns the names of the generators which
+// should be used for training on the current tier.
+func (c *Curriculum) Generators() []string {
+	var res []string
+	for _, tier := range c.Tiers[:c.Tier+1] {
+		res = append(res, tier.Generators...)
+	}
+	return res
+}
+
+// Update moves to the next tier if the validation accuracy
+// on the current tier passes its threshold.
+//
+// It returns true if the tier changed.
+func (c *Curriculum) Update(accuracy float64) bool {
+	if c.Done() || accuracy < c.Current().Threshold {
+		return false
+	}
+	c.Tier++
+	return true
+}
+
+// SetTier sets the current tier, for example when resuming
+// training from a checkpoint.
+func (c *Curriculum) SetTier(tier int) error {
+	if tier < 0 || tier >= len(c.Tiers) {
+		return errors.New("curriculum tier out of range: " + strconv.Itoa(tier))
+	}
+	c.Tier = tier
+	return nil
+}
@@ -0,0 +1,81 @@
+package algebrain
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseCurriculum(t *testing.T) {
+	c, err := ParseCurriculum("EasyShift,EasyScale:0.75;HardShift")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []*CurriculumTier{
+		{Generators: []string{"EasyShift", "EasyScale"}, Threshold: 0.75},
+		{Generators: []string{"HardShift"}, Threshold: DefaultCurriculumThreshold},
+	}
+	if !reflect.DeepEqual(c.Tiers, expected) {
+		t.Errorf("unexpected tiers: %+v %+v", *c.Tiers[0], *c.Tiers[1])
+	}
+	if c.Tier != 0 {
+		t.Errorf("expected tier 0 but got %d", c.Tier)
+	}
+
+	for _, s := range []string{
+		"EasyShift;Foo",
+		"EasyShift:high",
+		"EasyShift:",
+		"",
+	} {
+		if _, err := ParseCurriculum(s); err == nil {
+			t.Errorf("expected error for %q", s)
+		}
+	}
+}
+
+func TestCurriculumUpdate(t *testing.T) {
+	c, err := ParseCurriculum("EasyShift:0.5;EasyScale:0.8;HardShift")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if actual := c.Generators(); !reflect.DeepEqual(actual, []string{"EasyShift"}) {
+		t.Errorf("unexpected generators: %v", actual)
+	}
+	if c.Update(0.4) || c.Tier != 0 {
+		t.Fatal("promoted below threshold")
+	}
+	if !c.Update(0.5) || c.Tier != 1 {
+		t.Fatal("not promoted at threshold")
+	}
+	if c.Update(0.7) || c.Tier != 1 {
+		t.Fatal("promoted below threshold")
+	}
+	if !c.Update(0.9) || c.Tier != 2 {
+		t.Fatal("not promoted above threshold")
+	}
+	if !c.Done() {
+		t.Error("expected curriculum to be done")
+	}
+	if c.Update(1) || c.Tier != 2 {
+		t.Error("promoted past the last tier")
+	}
+	expected := []string{"EasyShift", "EasyScale", "HardShift"}
+	if actual := c.Generators(); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected generators %v but got %v", expected, actual)
+	}
+}
+
+func TestCurriculumSetTier(t *testing.T) {
+	c := DefaultCurriculum()
+	if err := c.SetTier(2); err != nil || c.Tier != 2 {
+		t.Fatalf("failed to set tier: %v", err)
+	}
+	for _, tier := range []int{-1, 3} {
+		if err := c.SetTier(tier); err == nil {
+			t.Errorf("expected error for tier %d", tier)
+		}
+	}
+	if c.Tier != 2 {
+		t.Errorf("tier changed to %d after error", c.Tier)
+	}
+}
@@ -0,0 +1,166 @@
+package algebrain
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+
+	"github.com/unixpickle/essentials"
+)
+
+// sampleRecord is the JSON representation of a Sample in a
+// JSONL dataset.
+type sampleRecord struct {
+	Query     string `json:"query"`
+	Response  string `json:"response"`
+	Generator string `json:"generator,omitempty"`
+	Depth     int    `json:"depth"`
+	Seed      int64  `json:"seed"`
+}
+
+// GenerateDataset generates samplesPer samples from each
+// of the named entries in Generators.
+//
+// Every sample is generated from its own seed, which is
+// derived from seed, so that individual samples can be
+// reproduced.
+// Since Generators use the global math/rand source, it is
+// re-seeded for every sample, which affects other users
+// of the global source.
+func GenerateDataset(names []string, samplesPer int, seed int64) (SampleList, error) {
+	gens, err := NamedGenerators(names)
+	if err != nil {
+		return nil, err
+	}
+	seeds := rand.New(rand.NewSource(seed))
+	var res SampleList
+	for i, gen := range gens {
+		for j := 0; j < samplesPer; j++ {
+			sampleSeed := seeds.Int63()
+			rand.Seed(sampleSeed)
+			sample := gen.Generate()
+			sample.GeneratorName = names[i]
+			sample.Seed = sampleSeed
+			res = append(res, sample)
+		}
+	}
+	return res, nil
+}
+
+// WriteSamples writes samples to w in JSONL format, with
+// one JSON object per line.
+func WriteSamples(w io.Writer, samples SampleList) error {
+	enc := json.NewEncoder(w)
+	for _, s := range samples {
+		err := enc.Encode(&sampleRecord{
+			Query:     s.Query,
+			Response:  s.Response,
+			Generator: s.GeneratorName,
+			Depth:     s.Depth,
+			Seed:      s.Seed,
+		})
+		if err != nil {
+			return essentials.AddCtx("write samples", err)
+		}
+	}
+	return nil
+}
+
+// ReadSamples reads samples in the format produced by
+// WriteSamples.
+// Blank lines are ignored.
+//
+// Every query and response is checked with ValidateString,
+// so that the samples can be used for training.
+func ReadSamples(r io.Reader) (SampleList, error) {
+	var res SampleList
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1<<24)
+	var lineNum int
+	for scanner.Scan() {
+		lineNum++
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var record sampleRecord
+		if err := json.Unmarshal(line, &record); err != nil {
+			return nil, essentials.AddCtx("read samples",
+				fmt.Errorf("line %d: %v", lineNum, err))
+		}
+		fields := []struct {
+			Name  string
+			Value string
+		}{{"query", record.Query}, {"response", record.Response}}
+		for _, field := range fields {
+			if err := ValidateString(field.Value); err != nil {
+				return nil, essentials.AddCtx("read samples",
+					fmt.Errorf("line %d: %s: %v", lineNum, field.Name, err))
+			}
+		}
+		res = append(res, &Sample{
+			Query:         record.Query,
+			Response:      record.Response,
+			GeneratorName: record.Generator,
+			Depth:         record.Depth,
+			Seed:          record.Seed,
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, essentials.AddCtx("read samples", err)
+	}
+	return res, nil
+}
+
+// SaveSamples writes samples to a JSONL file.
+func SaveSamples(path string, samples SampleList) error {
+	var buf bytes.Buffer
+	if err := WriteSamples(&buf, samples); err != nil {
+		return err
+	}
+	return WriteFileAtomic(path, buf.Bytes())
+}
+
+// LoadSamples reads samples from a JSONL file.
+func LoadSamples(path string) (SampleList, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, essentials.AddCtx("load samples", err)
+	}
+	defer f.Close()
+	return ReadSamples(f)
+}
+
+// GeneratorNames lists the distinct GeneratorName values
+// of the samples in order of first appearance.
+func (s SampleList) GeneratorNames() []string {
+	var res []string
+	seen := map[string]bool{}
+	for _, sample := range s {
+		if !seen[sample.GeneratorName] {
+			seen[sample.GeneratorName] = true
+			res = append(res, sample.GeneratorName)
+		}
+	}
+	return res
+}
+
+// Filter returns the samples produced by the named
+// generators.
+func (s SampleList) Filter(names []string) SampleList {
+	keep := map[string]bool{}
+	for _, name := range names {
+		keep[name] = true
+	}
+	var res SampleList
+	for _, sample := range s {
+		if keep[sample.GeneratorName] {
+			res = append(res, sample)
+		}
+	}
+	return res
+}
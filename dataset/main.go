@@ -0,0 +1,52 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/unixpickle/algebrain"
+	"github.com/unixpickle/essentials"
+)
+
+func main() {
+	var genNames string
+	var samplesPerGen int
+	var seed int64
+	var outFile string
+	var inFile string
+	flag.StringVar(&genNames, "generators",
+		strings.Join(algebrain.DefaultGeneratorNames, ","),
+		"comma-separated generator list")
+	flag.IntVar(&samplesPerGen, "samples", 1000, "samples per generator")
+	flag.Int64Var(&seed, "seed", 1337, "random seed for the dataset")
+	flag.StringVar(&outFile, "out", "", "JSONL file to generate")
+	flag.StringVar(&inFile, "in", "", "JSONL file to summarize")
+	flag.Parse()
+
+	if (outFile == "") == (inFile == "") {
+		essentials.Die("Exactly one of -out or -in must be specified.")
+	}
+
+	if outFile != "" {
+		samples, err := algebrain.GenerateDataset(strings.Split(genNames, ","),
+			samplesPerGen, seed)
+		if err != nil {
+			essentials.Die(err)
+		}
+		if err := algebrain.SaveSamples(outFile, samples); err != nil {
+			essentials.Die("Failed to save samples:", err)
+		}
+		fmt.Printf("Wrote %d samples to %s\n", len(samples), outFile)
+		return
+	}
+
+	samples, err := algebrain.LoadSamples(inFile)
+	if err != nil {
+		essentials.Die(err)
+	}
+	fmt.Printf("%d samples:\n", len(samples))
+	for _, name := range samples.GeneratorNames() {
+		fmt.Printf("  %-12s %d\n", name, len(samples.Filter([]string{name})))
+	}
+}
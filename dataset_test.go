@@ -0,0 +1,66 @@
+package algebrain
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSamplesRoundTrip(t *testing.T) {
+	samples := SampleList{
+		{
+			Query:         "shift x by 1 in x^2",
+			Response:      "(x+1)^2",
+			Depth:         2,
+			GeneratorName: "EasyShift",
+			Seed:          -1234567890123,
+		},
+		{
+			Query:    "evaluate 3*4",
+			Response: ResultPrefix + "12",
+			Depth:    1,
+		},
+	}
+	var buf bytes.Buffer
+	if err := WriteSamples(&buf, samples); err != nil {
+		t.Fatal(err)
+	}
+	data := "\n" + strings.Replace(buf.String(), "\n", "\n  \n", 1)
+	actual, err := ReadSamples(strings.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(actual, samples) {
+		t.Errorf("expected %v but got %v", samples, actual)
+	}
+}
+
+func TestReadSamplesError(t *testing.T) {
+	data := `{"query":"evaluate 1","response":"Result: 1","depth":0,"seed":1}
+
+{"query":"evaluate 2",
+`
+	_, err := ReadSamples(strings.NewReader(data))
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if !strings.Contains(err.Error(), "line 3") {
+		t.Errorf("error does not mention line 3: %v", err)
+	}
+}
+
+func TestReadSamplesInvalidRune(t *testing.T) {
+	for _, field := range []string{"query", "response"} {
+		data := `{"query":"evaluate 1","response":"Result: 1","depth":0,"seed":1}
+{"` + field + `":"2π","depth":0,"seed":1}
+`
+		_, err := ReadSamples(strings.NewReader(data))
+		if err == nil {
+			t.Fatalf("%s: expected error", field)
+		}
+		if !strings.Contains(err.Error(), "line 2: "+field) {
+			t.Errorf("%s: error does not mention line 2: %v", field, err)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package algebrain
+
+import (
+	"math"
+
+	"github.com/unixpickle/anydiff"
+	"github.com/unixpickle/anynet/anyrnn"
+	"github.com/unixpickle/anyvec"
+)
+
+// A stepDecoder runs the decoder of a Network on a single
+// query, one response character at a time.
+type stepDecoder struct {
+	net   *Network
+	chars []rune
+	enc   []anyvec.Vector
+	align anyrnn.Block
+}
+
+// A decoderState is the state of a stepDecoder after it
+// has produced part of a response.
+type decoderState struct {
+	rnn anyrnn.State
+
+	// query is the attention query for the next step.
+	query anyvec.Vector
+}
+
+// decoder creates a stepDecoder for the query.
+func (n *Network) decoder(q string) (*stepDecoder, error) {
+	enc, err := n.encode(q)
+	if err != nil {
+		return nil, err
+	}
+	res := &stepDecoder{
+		net:   n,
+		chars: []rune(q),
+		align: n.Align.Block(enc),
+	}
+	for _, batch := range enc.Output() {
+		res.enc = append(res.enc, batch.Packed)
+	}
+	return res, nil
+}
+
+// Start creates the initial decoder state.
+func (s *stepDecoder) Start() *decoderState {
+	return &decoderState{
+		rnn:   s.align.Start(1),
+		query: s.net.Align.InitQuery.Vector,
+	}
+}
+
+// Step feeds the previous response character (or the
+// Terminator on the first step) to the decoder.
+//
+// It returns the next state, the log probabilities for
+// the next character, and the attention weights over the
+// query characters used for this step.
+func (s *stepDecoder) Step(state *decoderState, last rune) (*decoderState,
+	[]float64, []float64) {
+	attention := s.net.attentionWeights(state.query, s.enc)
+	res := s.align.Step(state.rnn, oneHotVector(last))
+	query := res.Output()
+	logProbs := vectorFloats(s.net.Output.Apply(anydiff.NewConst(query), 1).Output())
+
+	if s.net.CopyGate != nil {
+		gateOut := s.net.CopyGate.Apply(anydiff.NewConst(query), 1).Output()
+		gate := vectorFloats(gateOut)[0]
+		probs := make([]float64, len(logProbs))
+		for i, x := range logProbs {
+			probs[i] = gate * math.Exp(x)
+		}
+		for i, ch := range s.chars {
+			probs[ch] += (1 - gate) * attention[i]
+		}
+		for i, x := range probs {
+			logProbs[i] = math.Log(x)
+		}
+	}
+
+	return &decoderState{rnn: res.State(), query: query}, logProbs, attention
+}
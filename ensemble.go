@@ -0,0 +1,125 @@
+package algebrain
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/unixpickle/essentials"
+	"github.com/unixpickle/serializer"
+)
+
+// An Ensemble combines the predictions of several
+// Models, such as Models trained with different random
+// seeds.
+type Ensemble struct {
+	Models []Model
+
+	// Vote, if set, makes each Model produce a full response
+	// and picks the most common one.
+	// Otherwise, responses are decoded greedily from the
+	// average of the Models' log probabilities, which is
+	// only supported if every Model is a *Network.
+	Vote bool
+}
+
+// LoadEnsemble loads an Ensemble from a list of files, each
+// of which contains a serialized Model.
+//
+// If any of the Models cannot be averaged, Vote is set.
+func LoadEnsemble(paths []string) (*Ensemble, error) {
+	res := &Ensemble{}
+	for _, path := range paths {
+		var model Model
+		if err := serializer.LoadAny(path, &model); err != nil {
+			return nil, essentials.AddCtx("load ensemble",
+				fmt.Errorf("%s: %v", path, err))
+		}
+		res.Models = append(res.Models, model)
+	}
+	res.Vote = !res.CanAverage()
+	return res, nil
+}
+
+// CanAverage returns true if the Models' predictions can
+// be averaged, i.e. if every Model is a *Network.
+func (e *Ensemble) CanAverage() bool {
+	for _, model := range e.Models {
+		if _, ok := model.(*Network); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// Query runs a query against the Ensemble.
+//
+// If the query contains an unsupported character, an
+// *InvalidRuneError is returned.
+func (e *Ensemble) Query(q string) (string, error) {
+	if len(e.Models) == 0 {
+		return "", errors.New("query ensemble: no models")
+	}
+	if e.Vote {
+		return e.queryVote(q)
+	}
+	return e.queryAverage(q)
+}
+
+func (e *Ensemble) queryAverage(q string) (string, error) {
+	decoders := make([]*stepDecoder, len(e.Models))
+	states := make([]*decoderState, len(e.Models))
+	for i, model := range e.Models {
+		net, ok := model.(*Network)
+		if !ok {
+			return "", fmt.Errorf("query ensemble: cannot average %T models "+
+				"(use voting instead)", model)
+		}
+		var err error
+		decoders[i], err = net.decoder(q)
+		if err != nil {
+			return "", err
+		}
+		states[i] = decoders[i].Start()
+	}
+
+	var lastChar rune
+	var res string
+	for len(res) < maxResponseLen {
+		meanLogProbs := make([]float64, CharCount)
+		for i, d := range decoders {
+			var logProbs []float64
+			states[i], logProbs, _ = d.Step(states[i], lastChar)
+			for j, x := range logProbs {
+				meanLogProbs[j] += x / float64(len(decoders))
+			}
+		}
+		lastChar = rune(maxIndex(meanLogProbs))
+		if lastChar == Terminator {
+			break
+		}
+		res += string(lastChar)
+	}
+	return res, nil
+}
+
+func (e *Ensemble) queryVote(q string) (string, error) {
+	var responses []string
+	votes := map[string]int{}
+	for _, model := range e.Models {
+		res, err := model.Query(q)
+		if err != nil {
+			return "", err
+		}
+		responses = append(responses, res)
+		votes[res]++
+	}
+
+	// Ties go to the response from the earliest Model.
+	best := responses[0]
+	for _, res := range responses[1:] {
+		if votes[res] > votes[best] {
+			best = res
+		}
+	}
+	return best, nil
+}
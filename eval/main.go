@@ -0,0 +1,149 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+
+	"github.com/unixpickle/algebrain"
+	"github.com/unixpickle/essentials"
+	"github.com/unixpickle/serializer"
+)
+
+// An accuracy tracks the results for a group of samples.
+type accuracy struct {
+	Total   int
+	Exact   int
+	Numeric int
+}
+
+func (a *accuracy) Add(v *algebrain.Verdict) {
+	a.Total++
+	if v.Exact {
+		a.Exact++
+	}
+	if v.Numeric {
+		a.Numeric++
+	}
+}
+
+func (a *accuracy) String() string {
+	return fmt.Sprintf("exact=%.2f%% numeric=%.2f%% (n=%d)",
+		100*float64(a.Exact)/float64(a.Total),
+		100*float64(a.Numeric)/float64(a.Total), a.Total)
+}
+
+func main() {
+	var netFile string
+	var genNames string
+	var samplesPerGen int
+	var seed int64
+	var batchSize int
+	var dataFile string
+	flag.StringVar(&netFile, "file", "out_net", "network file")
+	flag.StringVar(&genNames, "generators",
+		strings.Join(algebrain.DefaultGeneratorNames, ","),
+		"comma-separated generator list")
+	flag.IntVar(&samplesPerGen, "samples", 1000, "samples per generator")
+	flag.Int64Var(&seed, "seed", 1337,
+		"random seed for test samples (train uses 123)")
+	flag.IntVar(&batchSize, "batch", 32, "queries to run at once")
+	flag.StringVar(&dataFile, "data", "",
+		"JSONL test samples (instead of generating)")
+	flag.Parse()
+
+	if batchSize < 1 {
+		essentials.Die("Batch size must be at least 1.")
+	}
+
+	var net algebrain.Model
+	if err := serializer.LoadAny(netFile, &net); err != nil {
+		essentials.Die("Failed to load network:", err)
+	}
+
+	rand.Seed(seed)
+	var names []string
+	var groups []algebrain.SampleList
+	if dataFile != "" {
+		samples, err := algebrain.LoadSamples(dataFile)
+		if err != nil {
+			essentials.Die(err)
+		}
+		names = samples.GeneratorNames()
+		for _, name := range names {
+			groups = append(groups, samples.Filter([]string{name}))
+		}
+	} else {
+		names = strings.Split(genNames, ",")
+		gens, err := algebrain.NamedGenerators(names)
+		if err != nil {
+			essentials.Die(err)
+		}
+		for _, gen := range gens {
+			var samples algebrain.SampleList
+			for j := 0; j < samplesPerGen; j++ {
+				samples = append(samples, gen.Generate())
+			}
+			groups = append(groups, samples)
+		}
+	}
+
+	checker := &algebrain.Checker{Rand: rand.New(rand.NewSource(seed))}
+	total := &accuracy{}
+	byDepth := map[int]*accuracy{}
+
+	fmt.Println("Per generator:")
+	for i, samples := range groups {
+		queries := make([]string, len(samples))
+		for j, sample := range samples {
+			queries[j] = sample.Query
+		}
+		responses, err := queryBatches(net, queries, batchSize)
+		if err != nil {
+			essentials.Die("Failed to run queries:", err)
+		}
+
+		genAcc := &accuracy{}
+		for j, sample := range samples {
+			verdict := checker.Check(sample, responses[j])
+			genAcc.Add(verdict)
+			total.Add(verdict)
+			if byDepth[sample.Depth] == nil {
+				byDepth[sample.Depth] = &accuracy{}
+			}
+			byDepth[sample.Depth].Add(verdict)
+		}
+		fmt.Printf("  %-12s %s\n", names[i], genAcc)
+	}
+
+	fmt.Println("Per depth:")
+	var depths []int
+	for depth := range byDepth {
+		depths = append(depths, depth)
+	}
+	sort.Ints(depths)
+	for _, depth := range depths {
+		fmt.Printf("  %-12d %s\n", depth, byDepth[depth])
+	}
+
+	fmt.Printf("Overall: %s\n", total)
+}
+
+func queryBatches(net algebrain.Model, queries []string,
+	batchSize int) ([]string, error) {
+	var res []string
+	for i := 0; i < len(queries); i += batchSize {
+		end := i + batchSize
+		if end > len(queries) {
+			end = len(queries)
+		}
+		batch, err := net.QueryBatch(queries[i:end])
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, batch...)
+	}
+	return res, nil
+}
@@ -0,0 +1,89 @@
+package algebrain
+
+import (
+	"errors"
+
+	"github.com/unixpickle/algebrain/mathexpr"
+)
+
+// DefaultGeneratorNames lists the entries of Generators
+// which are used by default for training and evaluation.
+var DefaultGeneratorNames = []string{
+	"EasyShift", "MediumShift", "EasyScale", "MediumScale",
+	"EasyEval", "MediumEval", "HardShift", "HardScale",
+}
+
+// Generators maps names to the standard sample generators
+// used for training and evaluation.
+var Generators = map[string]Generator{
+	"EasyShift": &ShiftGenerator{
+		Generator: &mathexpr.Generator{
+			NoReals:  true,
+			VarNames: []string{"x"},
+		},
+		MaxDepth: 1,
+	},
+	"EasyScale": &ScaleGenerator{
+		Generator: &mathexpr.Generator{
+			NoReals:  true,
+			VarNames: []string{"x"},
+		},
+		MaxDepth: 1,
+	},
+	"EasyEval": &EvalGenerator{
+		Generator: &mathexpr.Generator{
+			NoReals: true,
+		},
+		MaxDepth: 1,
+		AllInts:  true,
+	},
+	"MediumShift": &ShiftGenerator{
+		Generator: &mathexpr.Generator{
+			NoReals:  true,
+			VarNames: []string{"x"},
+		},
+		MaxDepth: 3,
+	},
+	"MediumScale": &ScaleGenerator{
+		Generator: &mathexpr.Generator{
+			NoReals:  true,
+			VarNames: []string{"x"},
+		},
+		MaxDepth: 3,
+	},
+	"MediumEval": &EvalGenerator{
+		Generator: &mathexpr.Generator{
+			NoReals: true,
+			Stddev:  80,
+		},
+		MaxDepth: 3,
+		AllInts:  true,
+	},
+	"HardShift": &ShiftGenerator{
+		Generator: &mathexpr.Generator{
+			NoReals:  true,
+			VarNames: []string{"x", "y", "z"},
+		},
+		MaxDepth: 5,
+	},
+	"HardScale": &ScaleGenerator{
+		Generator: &mathexpr.Generator{
+			NoReals:  true,
+			VarNames: []string{"x", "y", "z"},
+		},
+		MaxDepth: 5,
+	},
+}
+
+// NamedGenerators looks up generators in Generators.
+func NamedGenerators(names []string) ([]Generator, error) {
+	res := make([]Generator, len(names))
+	for i, name := range names {
+		if gen, ok := Generators[name]; ok {
+			res[i] = gen
+		} else {
+			return nil, errors.New("unknown generator: " + name)
+		}
+	}
+	return res, nil
+}
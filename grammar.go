@@ -0,0 +1,113 @@
+package algebrain
+
+import (
+	"errors"
+	"math"
+
+	"github.com/unixpickle/algebrain/mathexpr"
+)
+
+// A responseGrammar tracks which characters may follow a
+// partial response so that it stays well-formed.
+//
+// A well-formed response is either an expression or
+// ResultPrefix followed by an expression.
+type responseGrammar struct {
+	// resultPos is the number of characters of ResultPrefix
+	// which have been matched, or -1 if the response does
+	// not start with ResultPrefix.
+	resultPos int
+
+	// expr is the state of the expression, or nil if the
+	// response cannot be an expression.
+	expr *mathexpr.Prefix
+}
+
+func newResponseGrammar() *responseGrammar {
+	return &responseGrammar{expr: &mathexpr.Prefix{}}
+}
+
+// Next computes the state after appending a character.
+//
+// It returns false if the response could no longer become
+// well-formed.
+func (r *responseGrammar) Next(ch rune) (*responseGrammar, bool) {
+	res := &responseGrammar{resultPos: -1}
+	if r.resultPos >= 0 && r.resultPos < len(ResultPrefix) {
+		if rune(ResultPrefix[r.resultPos]) == ch {
+			res.resultPos = r.resultPos + 1
+			if res.resultPos == len(ResultPrefix) {
+				res.expr = &mathexpr.Prefix{}
+				return res, true
+			}
+		}
+	}
+	if r.expr != nil {
+		res.expr, _ = r.expr.Next(ch)
+	}
+	return res, res.resultPos >= 0 || res.expr != nil
+}
+
+// Complete returns true if the response may end here.
+func (r *responseGrammar) Complete() bool {
+	return r.expr != nil && r.expr.Complete()
+}
+
+// Mask creates a copy of the log probabilities where
+// every disallowed character has probability zero.
+func (r *responseGrammar) Mask(logProbs []float64) []float64 {
+	res := make([]float64, len(logProbs))
+	for i, x := range logProbs {
+		var allowed bool
+		if rune(i) == Terminator {
+			allowed = r.Complete()
+		} else {
+			_, allowed = r.Next(rune(i))
+		}
+		if allowed {
+			res[i] = x
+		} else {
+			res[i] = math.Inf(-1)
+		}
+	}
+	return res
+}
+
+// ErrIncompleteResponse is returned by QueryGrammar when
+// decoding stops before the response is well-formed.
+var ErrIncompleteResponse = errors.New("response is incomplete")
+
+// QueryGrammar runs a query using greedy decoding, but
+// only allows characters which keep the response
+// well-formed.
+//
+// If no error is returned, the response is an expression
+// which mathexpr.Parse accepts, optionally preceded by
+// ResultPrefix.
+// If the response reaches the maximum length before it is
+// complete, the partial response is returned along with
+// ErrIncompleteResponse.
+func (n *Network) QueryGrammar(q string) (string, error) {
+	d, err := n.decoder(q)
+	if err != nil {
+		return "", err
+	}
+	state := d.Start()
+	grammar := newResponseGrammar()
+	var lastChar rune
+	var res string
+	for len(res) < maxResponseLen {
+		var logProbs []float64
+		state, logProbs, _ = d.Step(state, lastChar)
+		lastChar = rune(maxIndex(grammar.Mask(logProbs)))
+		if lastChar == Terminator {
+			break
+		}
+		grammar, _ = grammar.Next(lastChar)
+		res += string(lastChar)
+	}
+	if !grammar.Complete() {
+		return res, ErrIncompleteResponse
+	}
+	return res, nil
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"bytes"
+	"encoding/xml"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"math"
+
+	"github.com/unixpickle/algebrain"
+	"github.com/unixpickle/essentials"
+	"github.com/unixpickle/serializer"
+)
+
+const (
+	cellSize   = 16
+	labelSize  = 20
+	asciiShade = " .:-=+*#%@"
+)
+
+func main() {
+	var netFile string
+	var query string
+	var outFile string
+	flag.StringVar(&netFile, "file", "out_net", "network file")
+	flag.StringVar(&query, "query", "", "query to run")
+	flag.StringVar(&outFile, "out", "", "output SVG file (optional)")
+	flag.Parse()
+
+	if query == "" {
+		essentials.Die("Missing -query flag. See -help.")
+	}
+
+	var net *algebrain.Network
+	if err := serializer.LoadAny(netFile, &net); err != nil {
+		essentials.Die("Failed to load network:", err)
+	}
+
+	query = algebrain.NormalizeQuery(query)
+	response, weights, err := net.QueryAttention(query)
+	if err != nil {
+		essentials.Die("Failed to run query:", err)
+	}
+	fmt.Println("Response:", response)
+	fmt.Print(asciiHeatmap(query, response, weights))
+
+	if outFile != "" {
+		svg := svgHeatmap(query, response, weights)
+		if err := ioutil.WriteFile(outFile, svg, 0644); err != nil {
+			essentials.Die("Failed to write heatmap:", err)
+		}
+	}
+}
+
+// asciiHeatmap renders the attention weights as text, with
+// query characters along the top and response characters
+// down the left side.
+func asciiHeatmap(query, response string, weights [][]float64) string {
+	var res bytes.Buffer
+	res.WriteString("  " + query + "\n")
+	for i, row := range weights {
+		res.WriteByte(response[i])
+		res.WriteByte(' ')
+		for _, w := range row {
+			idx := int(math.Min(w, 1) * float64(len(asciiShade)-1))
+			res.WriteByte(asciiShade[idx])
+		}
+		res.WriteByte('\n')
+	}
+	return res.String()
+}
+
+// svgHeatmap renders the attention weights as an SVG
+// image, with query characters along the top and response
+// characters down the left side.
+func svgHeatmap(query, response string, weights [][]float64) []byte {
+	width := labelSize + cellSize*len(query)
+	height := labelSize + cellSize*len(response)
+
+	var res bytes.Buffer
+	fmt.Fprintf(&res, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d">`+"\n",
+		width, height)
+	fmt.Fprintf(&res, `<rect width="%d" height="%d" fill="white" />`+"\n", width, height)
+	for i, ch := range query {
+		x := labelSize + cellSize*i + cellSize/2
+		fmt.Fprintf(&res, `<text x="%d" y="%d" font-family="monospace" `+
+			`text-anchor="middle">%s</text>`+"\n", x, labelSize-6, escapeXML(ch))
+	}
+	for i, ch := range response {
+		y := labelSize + cellSize*i + cellSize - 4
+		fmt.Fprintf(&res, `<text x="%d" y="%d" font-family="monospace" `+
+			`text-anchor="middle">%s</text>`+"\n", labelSize/2, y, escapeXML(ch))
+		for j, w := range weights[i] {
+			shade := int(255 * (1 - math.Min(w, 1)))
+			fmt.Fprintf(&res, `<rect x="%d" y="%d" width="%d" height="%d" `+
+				`fill="rgb(%d,%d,%d)" />`+"\n", labelSize+cellSize*j,
+				labelSize+cellSize*i, cellSize, cellSize, shade, shade, shade)
+		}
+	}
+	res.WriteString("</svg>\n")
+	return res.Bytes()
+}
+
+func escapeXML(ch rune) string {
+	var res bytes.Buffer
+	xml.EscapeText(&res, []byte(string(ch)))
+	return res.String()
+}
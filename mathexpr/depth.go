@@ -0,0 +1,13 @@
+package mathexpr
+
+// Depth computes the nesting depth of an expression.
+// A node with no children has depth 0.
+func Depth(n Node) int {
+	var res int
+	for _, child := range n.Children() {
+		if d := Depth(child) + 1; d > res {
+			res = d
+		}
+	}
+	return res
+}
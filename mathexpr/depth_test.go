@@ -0,0 +1,22 @@
+package mathexpr
+
+import "testing"
+
+func TestDepth(t *testing.T) {
+	exprs := map[string]int{
+		"x":           0,
+		"-x":          1,
+		"2*3+3/2":     2,
+		"sin(-(x^2))": 3,
+		"f()":         0,
+	}
+	for str, expected := range exprs {
+		node, err := Parse(str)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual := Depth(node); actual != expected {
+			t.Errorf("depth of %q: expected %d got %d", str, expected, actual)
+		}
+	}
+}
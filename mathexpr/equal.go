@@ -0,0 +1,67 @@
+package mathexpr
+
+import "sort"
+
+// Equal checks if two expressions have the same tree
+// structure, operators, function names, and raw tokens.
+func Equal(n1, n2 Node) bool {
+	switch n1 := n1.(type) {
+	case RawNode:
+		n2, ok := n2.(RawNode)
+		return ok && n1 == n2
+	case *NegOp:
+		n2, ok := n2.(*NegOp)
+		return ok && Equal(n1.Node, n2.Node)
+	case *BinaryOp:
+		n2, ok := n2.(*BinaryOp)
+		return ok && n1.Op == n2.Op && Equal(n1.Left, n2.Left) &&
+			Equal(n1.Right, n2.Right)
+	case *FuncOp:
+		n2, ok := n2.(*FuncOp)
+		if !ok || n1.Name != n2.Name || len(n1.Args) != len(n2.Args) {
+			return false
+		}
+		for i, x := range n1.Args {
+			if !Equal(x, n2.Args[i]) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// Variables returns the sorted, de-duplicated names of the
+// raw tokens in an expression which are neither numbers
+// nor StandardConstNames.
+func Variables(n Node) []string {
+	found := map[string]bool{}
+	findVariables(n, found)
+	var res []string
+	for name := range found {
+		res = append(res, name)
+	}
+	sort.Strings(res)
+	return res
+}
+
+func findVariables(n Node, found map[string]bool) {
+	if raw, ok := n.(RawNode); ok {
+		if len(raw) > 0 && !isDigit(rune(raw[0])) && !isStandardConst(string(raw)) {
+			found[string(raw)] = true
+		}
+		return
+	}
+	for _, child := range n.Children() {
+		findVariables(child, found)
+	}
+}
+
+func isStandardConst(name string) bool {
+	for _, x := range StandardConstNames {
+		if x == name {
+			return true
+		}
+	}
+	return false
+}
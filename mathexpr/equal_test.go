@@ -0,0 +1,20 @@
+package mathexpr
+
+import "testing"
+
+func TestVariables(t *testing.T) {
+	node, err := Parse("sin(x)*pi+y^(2*x)-e/z1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	actual := Variables(node)
+	expected := []string{"x", "y", "z1"}
+	if len(actual) != len(expected) {
+		t.Fatalf("expected %v got %v", expected, actual)
+	}
+	for i, x := range expected {
+		if actual[i] != x {
+			t.Fatalf("expected %v got %v", expected, actual)
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package mathexpr
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// A DomainError is returned when an expression is
+// syntactically valid but cannot be evaluated for the
+// given inputs, e.g. due to division by zero.
+type DomainError struct {
+	// Expr is the sub-expression which failed.
+	Expr string
+
+	// Msg describes the problem.
+	Msg string
+}
+
+// Error returns a human-readable error message.
+func (d *DomainError) Error() string {
+	return "evaluate " + d.Expr + ": " + d.Msg
+}
+
+// Eval numerically evaluates an expression.
+//
+// Raw nodes are resolved by looking them up in env, then
+// in StandardConstNames, and finally by parsing them as
+// numbers.
+// The supported functions are StandardFuncNames.
+//
+// If the result of any sub-expression is undefined or not
+// finite, a *DomainError is returned.
+func Eval(n Node, env map[string]float64) (float64, error) {
+	switch n := n.(type) {
+	case RawNode:
+		return evalRaw(n, env)
+	case *NegOp:
+		x, err := Eval(n.Node, env)
+		if err != nil {
+			return 0, err
+		}
+		return -x, nil
+	case *BinaryOp:
+		return evalBinary(n, env)
+	case *FuncOp:
+		return evalFunc(n, env)
+	}
+	return 0, errors.New("evaluate " + n.String() + ": unsupported node")
+}
+
+func evalRaw(n RawNode, env map[string]float64) (float64, error) {
+	if val, ok := env[string(n)]; ok {
+		return val, nil
+	}
+	switch n {
+	case "e":
+		return math.E, nil
+	case "pi":
+		return math.Pi, nil
+	}
+	if len(n) > 0 && isDigit(rune(n[0])) {
+		if val, err := strconv.ParseFloat(string(n), 64); err == nil {
+			return val, nil
+		}
+	}
+	return 0, errors.New("evaluate " + string(n) + ": unbound identifier")
+}
+
+func evalBinary(n *BinaryOp, env map[string]float64) (float64, error) {
+	left, err := Eval(n.Left, env)
+	if err != nil {
+		return 0, err
+	}
+	right, err := Eval(n.Right, env)
+	if err != nil {
+		return 0, err
+	}
+	var res float64
+	switch n.Op {
+	case AddOp:
+		res = left + right
+	case SubtractOp:
+		res = left - right
+	case MultiplyOp:
+		res = left * right
+	case DivideOp:
+		if right == 0 {
+			return 0, &DomainError{Expr: n.String(), Msg: "division by zero"}
+		}
+		res = left / right
+	case PowOp:
+		res = math.Pow(left, right)
+	default:
+		return 0, errors.New("evaluate " + n.String() + ": unknown operator " + n.Op)
+	}
+	return checkFinite(n, res)
+}
+
+func evalFunc(n *FuncOp, env map[string]float64) (float64, error) {
+	if len(n.Args) != 1 {
+		return 0, fmt.Errorf("evaluate %s: expected 1 argument but got %d",
+			n, len(n.Args))
+	}
+	arg, err := Eval(n.Args[0], env)
+	if err != nil {
+		return 0, err
+	}
+	var res float64
+	switch n.Name {
+	case "sin":
+		res = math.Sin(arg)
+	case "cos":
+		res = math.Cos(arg)
+	case "tan":
+		res = math.Tan(arg)
+	case "exp":
+		res = math.Exp(arg)
+	case "ln":
+		if arg <= 0 {
+			return 0, &DomainError{Expr: n.String(), Msg: "logarithm of non-positive number"}
+		}
+		res = math.Log(arg)
+	default:
+		return 0, errors.New("evaluate " + n.String() + ": unknown function " + n.Name)
+	}
+	return checkFinite(n, res)
+}
+
+func checkFinite(n Node, x float64) (float64, error) {
+	if math.IsNaN(x) {
+		return 0, &DomainError{Expr: n.String(), Msg: "undefined result"}
+	} else if math.IsInf(x, 0) {
+		return 0, &DomainError{Expr: n.String(), Msg: "result is not finite"}
+	}
+	return x, nil
+}
@@ -0,0 +1,61 @@
+package mathexpr
+
+import (
+	"math"
+	"testing"
+)
+
+func TestEval(t *testing.T) {
+	env := map[string]float64{"x": 2, "y": -3}
+	cases := map[string]float64{
+		"2*3+3/2":       7.5,
+		"x^3-y":         11,
+		"-x^2":          -4,
+		"(-x)^2":        4,
+		"2^3^2":         512,
+		"sin(pi/2)":     1,
+		"cos(0)+exp(0)": 2,
+		"ln(e^x)":       2,
+		"tan(0)*y":      0,
+	}
+	for str, expected := range cases {
+		node, err := Parse(str)
+		if err != nil {
+			t.Fatal(err)
+		}
+		actual, err := Eval(node, env)
+		if err != nil {
+			t.Errorf("eval %q: %v", str, err)
+		} else if math.Abs(actual-expected) > 1e-8 {
+			t.Errorf("eval %q: expected %f got %f", str, expected, actual)
+		}
+	}
+}
+
+func TestEvalErrors(t *testing.T) {
+	env := map[string]float64{"x": 0}
+	domainErrs := []string{"1/x", "ln(x-1)", "ln(x)", "(x-2)^0.5", "x^(-1)"}
+	for _, str := range domainErrs {
+		node, err := Parse(str)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Eval(node, env); err == nil {
+			t.Errorf("expected error for %q", str)
+		} else if _, ok := err.(*DomainError); !ok {
+			t.Errorf("expected *DomainError for %q but got %v", str, err)
+		}
+	}
+	otherErrs := []string{"y+1", "foo(x)", "sin(x, x)"}
+	for _, str := range otherErrs {
+		node, err := Parse(str)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Eval(node, env); err == nil {
+			t.Errorf("expected error for %q", str)
+		} else if _, ok := err.(*DomainError); ok {
+			t.Errorf("unexpected *DomainError for %q", str)
+		}
+	}
+}
@@ -0,0 +1,243 @@
+package mathexpr
+
+import (
+	"fmt"
+	"unicode"
+)
+
+// A SyntaxError is returned when an expression cannot be
+// parsed.
+type SyntaxError struct {
+	// Pos is the byte offset of the offending character.
+	Pos int
+
+	// Msg describes the problem.
+	Msg string
+}
+
+// Error returns a human-readable error message.
+func (s *SyntaxError) Error() string {
+	return fmt.Sprintf("syntax error at position %d: %s", s.Pos, s.Msg)
+}
+
+// Parse parses an expression like the ones produced by
+// the String() method of a Node.
+//
+// Binary operators follow the usual precedence rules.
+// Addition, subtraction, multiplication, and division are
+// left-associative, while exponentiation is
+// right-associative.
+// An identifier followed by parentheses is treated as a
+// FuncOp with comma-separated arguments.
+func Parse(s string) (Node, error) {
+	p := &parser{str: s}
+	res, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	if p.pos < len(p.str) {
+		return nil, p.errorf("unexpected %q", p.str[p.pos])
+	}
+	return res, nil
+}
+
+type parser struct {
+	str string
+	pos int
+}
+
+func (p *parser) parseExpr() (Node, error) {
+	res, err := p.parseTerm()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op, ok := p.acceptOp(AddOp, SubtractOp)
+		if !ok {
+			return res, nil
+		}
+		right, err := p.parseTerm()
+		if err != nil {
+			return nil, err
+		}
+		res = &BinaryOp{Op: op, Left: res, Right: right}
+	}
+}
+
+func (p *parser) parseTerm() (Node, error) {
+	res, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op, ok := p.acceptOp(MultiplyOp, DivideOp)
+		if !ok {
+			return res, nil
+		}
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		res = &BinaryOp{Op: op, Left: res, Right: right}
+	}
+}
+
+func (p *parser) parseUnary() (Node, error) {
+	if _, ok := p.acceptOp(SubtractOp); ok {
+		child, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &NegOp{Node: child}, nil
+	}
+	return p.parsePow()
+}
+
+func (p *parser) parsePow() (Node, error) {
+	base, err := p.parseAtom()
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := p.acceptOp(PowOp); !ok {
+		return base, nil
+	}
+	exp, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	return &BinaryOp{Op: PowOp, Left: base, Right: exp}, nil
+}
+
+func (p *parser) parseAtom() (Node, error) {
+	p.skipSpace()
+	if p.pos == len(p.str) {
+		return nil, p.errorf("unexpected end of expression")
+	}
+	ch := rune(p.str[p.pos])
+	switch {
+	case ch == '(':
+		p.pos++
+		res, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(')'); err != nil {
+			return nil, err
+		}
+		return res, nil
+	case isDigit(ch):
+		return p.parseNumber()
+	case isIdentStart(ch):
+		name := p.parseIdent()
+		p.skipSpace()
+		if p.pos < len(p.str) && p.str[p.pos] == '(' {
+			p.pos++
+			args, err := p.parseArgs()
+			if err != nil {
+				return nil, err
+			}
+			return &FuncOp{Name: name, Args: args}, nil
+		}
+		return RawNode(name), nil
+	}
+	return nil, p.errorf("unexpected %q", p.str[p.pos])
+}
+
+func (p *parser) parseArgs() ([]Node, error) {
+	p.skipSpace()
+	if p.pos < len(p.str) && p.str[p.pos] == ')' {
+		p.pos++
+		return []Node{}, nil
+	}
+	var args []Node
+	for {
+		arg, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+		p.skipSpace()
+		if p.pos < len(p.str) && p.str[p.pos] == ',' {
+			p.pos++
+			continue
+		}
+		if err := p.expect(')'); err != nil {
+			return nil, err
+		}
+		return args, nil
+	}
+}
+
+func (p *parser) parseNumber() (Node, error) {
+	start := p.pos
+	for p.pos < len(p.str) && isDigit(rune(p.str[p.pos])) {
+		p.pos++
+	}
+	if p.pos < len(p.str) && p.str[p.pos] == '.' {
+		p.pos++
+		fracStart := p.pos
+		for p.pos < len(p.str) && isDigit(rune(p.str[p.pos])) {
+			p.pos++
+		}
+		if p.pos == fracStart {
+			return nil, p.errorf("expected digit after decimal point")
+		}
+	}
+	return RawNode(p.str[start:p.pos]), nil
+}
+
+func (p *parser) parseIdent() string {
+	start := p.pos
+	for p.pos < len(p.str) && isIdentPart(rune(p.str[p.pos])) {
+		p.pos++
+	}
+	return p.str[start:p.pos]
+}
+
+func (p *parser) acceptOp(ops ...string) (string, bool) {
+	p.skipSpace()
+	if p.pos == len(p.str) {
+		return "", false
+	}
+	for _, op := range ops {
+		if p.str[p.pos] == op[0] {
+			p.pos++
+			return op, true
+		}
+	}
+	return "", false
+}
+
+func (p *parser) expect(ch byte) error {
+	p.skipSpace()
+	if p.pos == len(p.str) {
+		return p.errorf("expected %q but got end of expression", ch)
+	} else if p.str[p.pos] != ch {
+		return p.errorf("expected %q but got %q", ch, p.str[p.pos])
+	}
+	p.pos++
+	return nil
+}
+
+func (p *parser) skipSpace() {
+	for p.pos < len(p.str) && p.str[p.pos] == ' ' {
+		p.pos++
+	}
+}
+
+func (p *parser) errorf(format string, args ...interface{}) error {
+	return &SyntaxError{Pos: p.pos, Msg: fmt.Sprintf(format, args...)}
+}
+
+func isDigit(ch rune) bool {
+	return ch >= '0' && ch <= '9'
+}
+
+func isIdentStart(ch rune) bool {
+	return ch < 0x80 && (unicode.IsLetter(ch) || ch == '_')
+}
+
+func isIdentPart(ch rune) bool {
+	return isIdentStart(ch) || isDigit(ch)
+}
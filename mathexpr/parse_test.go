@@ -0,0 +1,95 @@
+package mathexpr
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	cases := map[string]Node{
+		"2*3+3/2": &BinaryOp{
+			Left:  &BinaryOp{Left: RawNode("2"), Right: RawNode("3"), Op: "*"},
+			Right: &BinaryOp{Left: RawNode("3"), Right: RawNode("2"), Op: "/"},
+			Op:    "+",
+		},
+		"1-2-3": &BinaryOp{
+			Left:  &BinaryOp{Left: RawNode("1"), Right: RawNode("2"), Op: "-"},
+			Right: RawNode("3"),
+			Op:    "-",
+		},
+		"2^3^x": &BinaryOp{
+			Left:  RawNode("2"),
+			Right: &BinaryOp{Left: RawNode("3"), Right: RawNode("x"), Op: "^"},
+			Op:    "^",
+		},
+		"-x^2": &NegOp{
+			Node: &BinaryOp{Left: RawNode("x"), Right: RawNode("2"), Op: "^"},
+		},
+		"3*-x": &BinaryOp{
+			Left:  RawNode("3"),
+			Right: &NegOp{Node: RawNode("x")},
+			Op:    "*",
+		},
+		"((2-3)+3*2)^P(3, 2^x)": &BinaryOp{
+			Left: &BinaryOp{
+				Left:  &BinaryOp{Left: RawNode("2"), Right: RawNode("3"), Op: "-"},
+				Right: &BinaryOp{Left: RawNode("3"), Right: RawNode("2"), Op: "*"},
+				Op:    "+",
+			},
+			Right: &FuncOp{
+				Name: "P",
+				Args: []Node{
+					RawNode("3"),
+					&BinaryOp{Left: RawNode("2"), Right: RawNode("x"), Op: "^"},
+				},
+			},
+			Op: "^",
+		},
+		" sin( 2.5 ) - f()": &BinaryOp{
+			Left:  &FuncOp{Name: "sin", Args: []Node{RawNode("2.5")}},
+			Right: &FuncOp{Name: "f", Args: []Node{}},
+			Op:    "-",
+		},
+	}
+	for str, expected := range cases {
+		actual, err := Parse(str)
+		if err != nil {
+			t.Errorf("parse %q: %v", str, err)
+		} else if !Equal(actual, expected) {
+			t.Errorf("parse %q: expected %s got %s", str, expected, actual)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, str := range []string{"", "2+", "(2", "2)", "f(2,", "2.", "2x", "x*/2", "2#"} {
+		if _, err := Parse(str); err == nil {
+			t.Errorf("expected error for %q", str)
+		} else if _, ok := err.(*SyntaxError); !ok {
+			t.Errorf("unexpected error type for %q: %T", str, err)
+		}
+	}
+}
+
+func TestParseRoundTrip(t *testing.T) {
+	gen := &Generator{
+		FuncNames:  StandardFuncNames,
+		ConstNames: StandardConstNames,
+		VarNames:   []string{"x", "y", "z"},
+	}
+	rand.Seed(1337)
+	for i := 0; i < 1000; i++ {
+		expr := gen.Generate(6)
+		str := expr.String()
+		parsed, err := Parse(str)
+		if err != nil {
+			t.Fatalf("parse %q: %v", str, err)
+		}
+		if !Equal(parsed, expr) {
+			t.Fatalf("parse %q: got %s", str, parsed)
+		}
+		if parsed.String() != str {
+			t.Fatalf("parse %q: re-encoded as %q", str, parsed.String())
+		}
+	}
+}
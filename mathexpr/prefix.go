@@ -0,0 +1,153 @@
+package mathexpr
+
+import "strings"
+
+type prefixState int
+
+const (
+	// expectOperand is the state at the start of an
+	// expression and after operators, commas, and opening
+	// parentheses.
+	expectOperand prefixState = iota
+
+	// afterOperand is the state after a complete operand,
+	// possibly followed by spaces.
+	afterOperand
+
+	inNumber
+	afterDecimal
+	inFraction
+	inIdent
+)
+
+// A Prefix is a state machine which tracks whether a
+// partial string can be extended into an expression that
+// Parse accepts.
+//
+// The zero value is the state for an empty string.
+// A Prefix is immutable, so earlier states may be kept
+// around while exploring different continuations.
+type Prefix struct {
+	state prefixState
+
+	// calls has one entry per open parenthesis, which is
+	// true if the parenthesis started a function call.
+	calls []bool
+
+	// bareIdent is set after an identifier (and any spaces)
+	// which could still become a function call.
+	bareIdent bool
+
+	// emptyArgs is set right after a function call's
+	// opening parenthesis, where ")" is allowed.
+	emptyArgs bool
+}
+
+// NewPrefix creates the state for a string.
+//
+// It returns false if the string cannot be extended into
+// a valid expression.
+func NewPrefix(s string) (*Prefix, bool) {
+	p := &Prefix{}
+	for _, ch := range s {
+		var ok bool
+		if p, ok = p.Next(ch); !ok {
+			return nil, false
+		}
+	}
+	return p, true
+}
+
+// Complete returns true if the prefix is a valid
+// expression on its own.
+func (p *Prefix) Complete() bool {
+	return p.state != expectOperand && p.state != afterDecimal && len(p.calls) == 0
+}
+
+// Next computes the state after appending a character.
+//
+// It returns false if the result could not be extended
+// into a valid expression.
+func (p *Prefix) Next(ch rune) (*Prefix, bool) {
+	switch p.state {
+	case expectOperand:
+		return p.nextOperand(ch)
+	case inNumber:
+		if isDigit(ch) {
+			return p, true
+		} else if ch == '.' {
+			return p.with(afterDecimal), true
+		}
+	case afterDecimal:
+		if isDigit(ch) {
+			return p.with(inFraction), true
+		}
+		return nil, false
+	case inFraction:
+		if isDigit(ch) {
+			return p, true
+		}
+	case inIdent:
+		if isIdentPart(ch) {
+			return p, true
+		}
+	}
+	return p.nextOperator(ch)
+}
+
+func (p *Prefix) nextOperand(ch rune) (*Prefix, bool) {
+	switch {
+	case ch == ' ':
+		return p, true
+	case ch == '-':
+		return p.with(expectOperand), true
+	case ch == '(':
+		return p.push(false), true
+	case ch == ')' && p.emptyArgs:
+		return p.pop(), true
+	case isDigit(ch):
+		return p.with(inNumber), true
+	case isIdentStart(ch):
+		res := p.with(inIdent)
+		res.bareIdent = true
+		return res, true
+	}
+	return nil, false
+}
+
+func (p *Prefix) nextOperator(ch rune) (*Prefix, bool) {
+	switch {
+	case ch == ' ':
+		res := p.with(afterOperand)
+		res.bareIdent = p.bareIdent
+		return res, true
+	case strings.ContainsRune(AddOp+SubtractOp+MultiplyOp+DivideOp+PowOp, ch):
+		return p.with(expectOperand), true
+	case ch == '(' && p.bareIdent:
+		res := p.push(true)
+		res.emptyArgs = true
+		return res, true
+	case ch == ')' && len(p.calls) > 0:
+		return p.pop(), true
+	case ch == ',' && len(p.calls) > 0 && p.calls[len(p.calls)-1]:
+		return p.with(expectOperand), true
+	}
+	return nil, false
+}
+
+// with creates a copy of p in a new state.
+func (p *Prefix) with(state prefixState) *Prefix {
+	return &Prefix{state: state, calls: p.calls}
+}
+
+func (p *Prefix) push(call bool) *Prefix {
+	res := p.with(expectOperand)
+	res.calls = append(append([]bool{}, p.calls...), call)
+	return res
+}
+
+func (p *Prefix) pop() *Prefix {
+	res := p.with(afterOperand)
+	res.calls = p.calls[:len(p.calls)-1]
+	return res
+}
@@ -0,0 +1,54 @@
+package mathexpr
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestPrefix(t *testing.T) {
+	valid := []string{"", "(", "sin (", "f()", "2.", "-(-x", "3*-", "P(3, 2^x"}
+	for _, s := range valid {
+		if _, ok := NewPrefix(s); !ok {
+			t.Errorf("expected %q to be a valid prefix", s)
+		}
+	}
+	invalid := []string{")", "2x", "x y", "2(", "f(,", "(3,", "2.+", "1 .5", "x()("}
+	for _, s := range invalid {
+		if _, ok := NewPrefix(s); ok {
+			t.Errorf("expected %q to be an invalid prefix", s)
+		}
+	}
+}
+
+func TestPrefixMatchesParse(t *testing.T) {
+	const alphabet = "x1.+-*^(), f"
+	for i := 0; i < 100000; i++ {
+		str := make([]byte, rand.Intn(9))
+		for j := range str {
+			str[j] = alphabet[rand.Intn(len(alphabet))]
+		}
+		s := string(str)
+		_, parseErr := Parse(s)
+		p, ok := NewPrefix(s)
+		if parseErr == nil && (!ok || !p.Complete()) {
+			t.Fatalf("%q parses but is not a complete prefix", s)
+		} else if parseErr != nil && ok && p.Complete() {
+			t.Fatalf("%q is a complete prefix but fails to parse: %v", s, parseErr)
+		}
+	}
+}
+
+func TestPrefixGenerated(t *testing.T) {
+	gen := &Generator{
+		FuncNames:  StandardFuncNames,
+		ConstNames: StandardConstNames,
+		VarNames:   []string{"x", "y", "z"},
+	}
+	for i := 0; i < 1000; i++ {
+		s := gen.Generate(6).String()
+		p, ok := NewPrefix(s)
+		if !ok || !p.Complete() {
+			t.Fatalf("%q is not a complete prefix", s)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package algebrain
+
+import (
+	"errors"
+	"math/rand"
+	"strconv"
+	"strings"
+
+	"github.com/unixpickle/essentials"
+)
+
+// A MixtureComponent is a weighted child of a
+// MixtureGenerator.
+type MixtureComponent struct {
+	// Name is stored in the GeneratorName field of every
+	// Sample from this component.
+	Name string
+
+	Generator Generator
+	Weight    float64
+}
+
+// A MixtureGenerator chooses a child Generator at random
+// for each Sample, with probability proportional to the
+// child's weight.
+type MixtureGenerator struct {
+	Components []*MixtureComponent
+}
+
+// ParseMixture creates a MixtureGenerator from a string
+// like "EasyShift:1,HardShift:3", where each name refers
+// to an entry in Generators.
+//
+// A name without a weight is given a weight of 1.
+func ParseMixture(s string) (*MixtureGenerator, error) {
+	res := &MixtureGenerator{}
+	for _, part := range strings.Split(s, ",") {
+		name := part
+		weight := 1.0
+		if idx := strings.LastIndex(part, ":"); idx >= 0 {
+			name = part[:idx]
+			var err error
+			weight, err = strconv.ParseFloat(part[idx+1:], 64)
+			if err != nil {
+				return nil, essentials.AddCtx("parse mixture", err)
+			}
+			if weight < 0 {
+				return nil, errors.New("parse mixture: negative weight for " + name)
+			}
+		}
+		gen, ok := Generators[name]
+		if !ok {
+			return nil, errors.New("parse mixture: unknown generator: " + name)
+		}
+		res.Components = append(res.Components, &MixtureComponent{
+			Name:      name,
+			Generator: gen,
+			Weight:    weight,
+		})
+	}
+	return res, nil
+}
+
+// Generate generates a Sample from a random component.
+func (m *MixtureGenerator) Generate() *Sample {
+	var total float64
+	for _, c := range m.Components {
+		total += c.Weight
+	}
+	x := rand.Float64() * total
+	component := m.Components[len(m.Components)-1]
+	for _, c := range m.Components {
+		x -= c.Weight
+		if x < 0 {
+			component = c
+			break
+		}
+	}
+	sample := component.Generator.Generate()
+	sample.GeneratorName = component.Name
+	return sample
+}
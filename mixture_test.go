@@ -0,0 +1,54 @@
+package algebrain
+
+import "testing"
+
+func TestParseMixture(t *testing.T) {
+	m, err := ParseMixture("EasyShift:0.5,HardScale,MediumEval:3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []struct {
+		Name   string
+		Weight float64
+	}{
+		{"EasyShift", 0.5},
+		{"HardScale", 1},
+		{"MediumEval", 3},
+	}
+	if len(m.Components) != len(expected) {
+		t.Fatalf("expected %d components but got %d", len(expected), len(m.Components))
+	}
+	for i, x := range expected {
+		c := m.Components[i]
+		if c.Name != x.Name || c.Weight != x.Weight || c.Generator != Generators[x.Name] {
+			t.Errorf("component %d: expected %s:%f but got %s:%f", i, x.Name, x.Weight,
+				c.Name, c.Weight)
+		}
+	}
+
+	for _, s := range []string{
+		"EasyShift,Foo",
+		"Foo:1",
+		"EasyShift:heavy",
+		"EasyShift:-1",
+		"",
+	} {
+		if _, err := ParseMixture(s); err == nil {
+			t.Errorf("expected error for %q", s)
+		}
+	}
+}
+
+func TestMixtureGeneratorName(t *testing.T) {
+	m, err := ParseMixture("EasyShift:1,EasyEval:1,HardScale:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	counts := map[string]int{}
+	for i := 0; i < 200; i++ {
+		counts[m.Generate().GeneratorName]++
+	}
+	if len(counts) != 2 || counts["EasyShift"] == 0 || counts["EasyEval"] == 0 {
+		t.Errorf("unexpected generator names: %v", counts)
+	}
+}
@@ -0,0 +1,34 @@
+package algebrain
+
+import (
+	"github.com/unixpickle/anydiff/anyseq"
+	"github.com/unixpickle/anynet"
+	"github.com/unixpickle/anyvec"
+	"github.com/unixpickle/serializer"
+)
+
+// A Model produces responses to queries.
+//
+// Network and Transformer both implement Model, so either
+// one can be trained with a Trainer.
+type Model interface {
+	anynet.Parameterizer
+	serializer.Serializer
+
+	// Query runs a query against the model.
+	Query(q string) (string, error)
+
+	// QueryBatch runs a batch of queries against the model.
+	// The responses are returned in the same order as the
+	// queries.
+	QueryBatch(qs []string) ([]string, error)
+
+	// LogProbs applies the model to a batch of queries while
+	// feeding decIn to the decoder, producing the log
+	// probabilities for each character of the responses.
+	LogProbs(encIn, decIn anyseq.Seq) anyseq.Seq
+}
+
+func modelCreator(m Model) anyvec.Creator {
+	return m.Parameters()[0].Vector.Creator()
+}
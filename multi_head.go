@@ -0,0 +1,215 @@
+package algebrain
+
+import (
+	"math"
+
+	"github.com/unixpickle/anydiff"
+	"github.com/unixpickle/anynet"
+	"github.com/unixpickle/anyvec"
+	"github.com/unixpickle/anyvec/anyvecsave"
+	"github.com/unixpickle/essentials"
+	"github.com/unixpickle/serializer"
+)
+
+func init() {
+	var m MultiHeadAttention
+	serializer.RegisterTypedDeserializer(m.SerializerType(), DeserializeMultiHeadAttention)
+}
+
+// MultiHeadAttention implements scaled dot-product
+// attention with several heads.
+//
+// The projection matrices are stored with one block of
+// rows per head, so that each head's projection is a
+// contiguous slice of the weights.
+type MultiHeadAttention struct {
+	Heads     int
+	ModelSize int
+
+	// Query, Key, and Value are ModelSize by ModelSize
+	// matrices which project inputs for each head.
+	Query *anydiff.Var
+	Key   *anydiff.Var
+	Value *anydiff.Var
+
+	// Output is a ModelSize by ModelSize matrix mapping the
+	// concatenated head outputs back to the model.
+	Output     *anydiff.Var
+	OutputBias *anydiff.Var
+}
+
+// DeserializeMultiHeadAttention deserializes a
+// MultiHeadAttention.
+func DeserializeMultiHeadAttention(d []byte) (*MultiHeadAttention, error) {
+	var heads, modelSize serializer.Int
+	var query, key, value, output, outputBias *anyvecsave.S
+	err := serializer.DeserializeAny(d, &heads, &modelSize, &query, &key, &value,
+		&output, &outputBias)
+	if err != nil {
+		return nil, essentials.AddCtx("deserialize MultiHeadAttention", err)
+	}
+	return &MultiHeadAttention{
+		Heads:      int(heads),
+		ModelSize:  int(modelSize),
+		Query:      anydiff.NewVar(query.Vector),
+		Key:        anydiff.NewVar(key.Vector),
+		Value:      anydiff.NewVar(value.Vector),
+		Output:     anydiff.NewVar(output.Vector),
+		OutputBias: anydiff.NewVar(outputBias.Vector),
+	}, nil
+}
+
+// NewMultiHeadAttention creates a randomly-initialized
+// MultiHeadAttention.
+//
+// The model size must be divisible by the number of heads.
+func NewMultiHeadAttention(c anyvec.Creator, heads, modelSize int) *MultiHeadAttention {
+	if modelSize%heads != 0 {
+		panic("model size must be divisible by head count")
+	}
+	return &MultiHeadAttention{
+		Heads:      heads,
+		ModelSize:  modelSize,
+		Query:      anynet.NewFC(c, modelSize, modelSize).Weights,
+		Key:        anynet.NewFC(c, modelSize, modelSize).Weights,
+		Value:      anynet.NewFC(c, modelSize, modelSize).Weights,
+		Output:     anynet.NewFC(c, modelSize, modelSize).Weights,
+		OutputBias: anydiff.NewVar(c.MakeVector(modelSize)),
+	}
+}
+
+// Apply computes attention for each row of in over the
+// rows of mem.
+//
+// If causal is set, row i of in may only attend to rows
+// 0 through i of mem.
+func (m *MultiHeadAttention) Apply(in anydiff.Res, inLen int, mem anydiff.Res,
+	memLen int, causal bool) anydiff.Res {
+	c := in.Output().Creator()
+	d := m.ModelSize
+	hs := d / m.Heads
+
+	// Transposed projections, with one block of hs rows per
+	// head and one column per timestep.
+	queries := matMul(false, true, m.Query, d, d, in, inLen, d)
+	keys := matMul(false, true, m.Key, d, d, mem, memLen, d)
+	values := matMul(false, true, m.Value, d, d, mem, memLen, d)
+
+	var res anydiff.Res
+	for h := 0; h < m.Heads; h++ {
+		q := anydiff.Slice(queries, h*hs*inLen, (h+1)*hs*inLen)
+		k := anydiff.Slice(keys, h*hs*memLen, (h+1)*hs*memLen)
+		v := anydiff.Slice(values, h*hs*memLen, (h+1)*hs*memLen)
+
+		scores := matMul(true, false, q, hs, inLen, k, hs, memLen)
+		scores = anydiff.Scale(scores, c.MakeNumeric(1/math.Sqrt(float64(hs))))
+		if causal {
+			scores = anydiff.Add(scores, anydiff.NewConst(causalMask(c, inLen, memLen)))
+		}
+		weights := anydiff.Exp(anydiff.LogSoftmax(scores, memLen))
+		context := matMul(false, true, weights, inLen, memLen, v, hs, memLen)
+
+		outProj := anydiff.Slice(m.Output, h*hs*d, (h+1)*hs*d)
+		headOut := matMul(false, false, context, inLen, hs, outProj, hs, d)
+		if res == nil {
+			res = headOut
+		} else {
+			res = anydiff.Add(res, headOut)
+		}
+	}
+	return anydiff.AddRepeated(res, m.OutputBias)
+}
+
+// Parameters returns the parameters of the layer.
+func (m *MultiHeadAttention) Parameters() []*anydiff.Var {
+	return []*anydiff.Var{m.Query, m.Key, m.Value, m.Output, m.OutputBias}
+}
+
+// SerializerType returns the unique ID used to serialize
+// a MultiHeadAttention with the serializer package.
+func (m *MultiHeadAttention) SerializerType() string {
+	return "github.com/unixpickle/algebrain.MultiHeadAttention"
+}
+
+// Serialize attempts to serialize the layer.
+func (m *MultiHeadAttention) Serialize() ([]byte, error) {
+	return serializer.SerializeAny(
+		serializer.Int(m.Heads),
+		serializer.Int(m.ModelSize),
+		&anyvecsave.S{Vector: m.Query.Vector},
+		&anyvecsave.S{Vector: m.Key.Vector},
+		&anyvecsave.S{Vector: m.Value.Vector},
+		&anyvecsave.S{Vector: m.Output.Vector},
+		&anyvecsave.S{Vector: m.OutputBias.Vector},
+	)
+}
+
+// layerNorm normalizes each row of a matrix to have zero
+// mean and unit variance, then applies an affine
+// transformation to each row.
+func layerNorm(x anydiff.Res, rows, cols int, affine *anynet.Affine) anydiff.Res {
+	c := x.Output().Creator()
+	averager := filledConst(c, cols, 1/float64(cols))
+	ones := filledConst(c, cols, 1)
+
+	mean := matMul(false, false, x, rows, cols, averager, cols, 1)
+	centered := anydiff.Sub(x, matMul(false, false, mean, rows, 1, ones, 1, cols))
+	variance := matMul(false, false, anydiff.Square(centered), rows, cols,
+		averager, cols, 1)
+	invStd := anydiff.Pow(anydiff.AddScalar(variance, c.MakeNumeric(1e-5)),
+		c.MakeNumeric(-0.5))
+	normalized := anydiff.Mul(centered, matMul(false, false, invStd, rows, 1,
+		ones, 1, cols))
+	return affine.Apply(normalized, rows)
+}
+
+func newLayerNormAffine(c anyvec.Creator, size int) *anynet.Affine {
+	scalers := c.MakeVector(size)
+	scalers.AddScalar(c.MakeNumeric(1))
+	return &anynet.Affine{
+		Scalers: anydiff.NewVar(scalers),
+		Biases:  anydiff.NewVar(c.MakeVector(size)),
+	}
+}
+
+func matMul(transA, transB bool, a anydiff.Res, aRows, aCols int, b anydiff.Res,
+	bRows, bCols int) anydiff.Res {
+	return anydiff.MatMul(transA, transB,
+		&anydiff.Matrix{Data: a, Rows: aRows, Cols: aCols},
+		&anydiff.Matrix{Data: b, Rows: bRows, Cols: bCols}).Data
+}
+
+func filledConst(c anyvec.Creator, size int, value float64) anydiff.Res {
+	vec := c.MakeVector(size)
+	vec.AddScalar(c.MakeNumeric(value))
+	return anydiff.NewConst(vec)
+}
+
+// causalMask creates a matrix which is added to attention
+// scores to prevent rows from attending to later columns.
+func causalMask(c anyvec.Creator, rows, cols int) anyvec.Vector {
+	data := make([]float64, rows*cols)
+	for i := 0; i < rows; i++ {
+		for j := i + 1; j < cols; j++ {
+			data[i*cols+j] = -1e9
+		}
+	}
+	return c.MakeVectorData(c.MakeNumericList(data))
+}
+
+// positionalEncoding creates sinusoidal position encodings
+// for a sequence, stored as a row-major matrix.
+func positionalEncoding(c anyvec.Creator, length, size int) anyvec.Vector {
+	data := make([]float64, length*size)
+	for pos := 0; pos < length; pos++ {
+		for i := 0; i < size; i++ {
+			freq := math.Pow(10000, -float64(i-i%2)/float64(size))
+			if i%2 == 0 {
+				data[pos*size+i] = math.Sin(float64(pos) * freq)
+			} else {
+				data[pos*size+i] = math.Cos(float64(pos) * freq)
+			}
+		}
+	}
+	return c.MakeVectorData(c.MakeNumericList(data))
+}
@@ -1,6 +1,10 @@
 package algebrain
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
 	"github.com/unixpickle/anydiff"
 	"github.com/unixpickle/anydiff/anyseq"
 	"github.com/unixpickle/anynet"
@@ -15,10 +19,6 @@ const (
 	CharCount  = 0x80
 	Terminator = 0
 
-	querySize     = 0x80
-	encodedSize   = 0x40
-	decoderInSize = CharCount + encodedSize
-
 	maxResponseLen = 0x400
 )
 
@@ -30,71 +30,138 @@ func init() {
 // A Network uses a machine translation architecture to
 // produce output expressions for input queries.
 type Network struct {
+	// Config is the architecture of the network.
+	Config *NetworkConfig
+
 	Encoder *anyrnn.Bidir
 	Align   *attention.SoftAlign
 	Output  anynet.Net
+
+	// CopyGate computes the probability of generating a
+	// character rather than copying one from the query.
+	// It is nil unless Config.Copy is set.
+	CopyGate anynet.Net
 }
 
 // DeserializeNetwork deserializes a Network.
+//
+// Networks which were saved without a configuration are
+// assumed to use DefaultNetworkConfig.
 func DeserializeNetwork(d []byte) (*Network, error) {
-	var res Network
-	err := serializer.DeserializeAny(d, &res.Encoder, &res.Align, &res.Output)
+	parts, err := serializer.DeserializeSlice(d)
 	if err != nil {
 		return nil, essentials.AddCtx("deserialize Network", err)
 	}
-	return &res, nil
+	res := &Network{Config: DefaultNetworkConfig()}
+
+	// The legacy format has no configuration and only
+	// includes the encoder, aligner, and output layer.
+	if len(parts) != 3 {
+		if len(parts) < 4 {
+			return nil, errors.New("deserialize Network: missing components")
+		}
+		cfgData, ok := parts[3].(serializer.Bytes)
+		if !ok {
+			return nil, errors.New("deserialize Network: invalid configuration")
+		}
+		res.Config = nil
+		if err := json.Unmarshal([]byte(cfgData), &res.Config); err != nil {
+			return nil, essentials.AddCtx("deserialize Network", err)
+		}
+		expected := 4
+		if res.Config.Copy {
+			expected = 5
+		}
+		if len(parts) != expected {
+			return nil, fmt.Errorf("deserialize Network: expected %d components but got %d",
+				expected, len(parts))
+		}
+	}
+
+	var ok1, ok2, ok3 bool
+	res.Encoder, ok1 = parts[0].(*anyrnn.Bidir)
+	res.Align, ok2 = parts[1].(*attention.SoftAlign)
+	res.Output, ok3 = parts[2].(anynet.Net)
+	if !ok1 || !ok2 || !ok3 {
+		return nil, errors.New("deserialize Network: unexpected component types")
+	}
+	if res.Config.Copy {
+		var ok bool
+		if res.CopyGate, ok = parts[4].(anynet.Net); !ok {
+			return nil, errors.New("deserialize Network: invalid copy gate")
+		}
+	}
+	return res, nil
 }
 
-// NewNetwork creates a randomly-initialized Network.
+// NewNetwork creates a randomly-initialized Network with
+// the default configuration.
 func NewNetwork(c anyvec.Creator) *Network {
-	inScaler := c.MakeNumeric(16)
+	return NewNetworkConfig(c, DefaultNetworkConfig())
+}
+
+// NewNetworkConfig creates a randomly-initialized Network
+// with the given architecture.
+//
+// It panics if the configuration is invalid, so untrusted
+// configurations should be checked with cfg.Validate.
+func NewNetworkConfig(c anyvec.Creator, cfg *NetworkConfig) *Network {
+	if err := cfg.Validate(); err != nil {
+		panic(err)
+	}
+	inScaler := c.MakeNumeric(cfg.InScale)
 	encoder := &anyrnn.Bidir{
-		Forward: anyrnn.Stack{
-			anyrnn.NewLSTM(c, CharCount, 0x100).ScaleInWeights(inScaler),
-			anyrnn.NewLSTM(c, 0x100, encodedSize),
-		},
-		Backward: anyrnn.Stack{
-			anyrnn.NewLSTM(c, CharCount, 0x100).ScaleInWeights(inScaler),
-			anyrnn.NewLSTM(c, 0x100, encodedSize),
-		},
+		Forward: cellStack(c, cfg.EncoderCell, CharCount, cfg.EncoderHidden,
+			cfg.EncodedSize, cfg.EncoderLayers, inScaler),
+		Backward: cellStack(c, cfg.EncoderCell, CharCount, cfg.EncoderHidden,
+			cfg.EncodedSize, cfg.EncoderLayers, inScaler),
 		Mixer: &anynet.AddMixer{
-			In1: anynet.NewFC(c, encodedSize, encodedSize),
-			In2: anynet.NewFC(c, encodedSize, encodedSize),
+			In1: anynet.NewFC(c, cfg.EncodedSize, cfg.EncodedSize),
+			In2: anynet.NewFC(c, cfg.EncodedSize, cfg.EncodedSize),
 			Out: anynet.Tanh,
 		},
 	}
-	decoderBlock := anyrnn.Stack{
-		anyrnn.NewLSTM(c, decoderInSize, 0x100),
-		anyrnn.NewLSTM(c, 0x100, querySize),
-	}
+	decoderInSize := CharCount + cfg.EncodedSize
+	decoderBlock := cellStack(c, cfg.DecoderCell, decoderInSize, cfg.DecoderHidden,
+		cfg.QuerySize, cfg.DecoderLayers, nil)
 	inComb := &anynet.AddMixer{
-		In1: anynet.NewFC(c, encodedSize, decoderInSize),
+		In1: anynet.NewFC(c, cfg.EncodedSize, decoderInSize),
 		In2: anynet.NewFC(c, CharCount, decoderInSize),
 		Out: anynet.Tanh,
 	}
 	inComb.In2.(*anynet.FC).Weights.Vector.Scale(inScaler)
 	attentor := &anynet.AddMixer{
-		In1: anynet.NewFC(c, querySize, 0x80),
-		In2: anynet.NewFC(c, encodedSize, 0x80),
+		In1: anynet.NewFC(c, cfg.QuerySize, cfg.AttentionSize),
+		In2: anynet.NewFC(c, cfg.EncodedSize, cfg.AttentionSize),
 		Out: anynet.Net{
 			anynet.Tanh,
-			anynet.NewFC(c, 0x80, 1),
+			anynet.NewFC(c, cfg.AttentionSize, 1),
 			&anynet.Affine{
 				Scalers: anydiff.NewVar(c.MakeVectorData(c.MakeNumericList([]float64{5}))),
 				Biases:  anydiff.NewVar(c.MakeVectorData(c.MakeNumericList([]float64{0}))),
 			},
 		},
 	}
+	var copyGate anynet.Net
+	if cfg.Copy {
+		copyGate = anynet.Net{
+			anynet.NewFC(c, cfg.QuerySize, 1),
+			anynet.Sigmoid,
+		}
+	}
+	cfgCopy := *cfg
 	return &Network{
-		Encoder: encoder,
+		Config:   &cfgCopy,
+		CopyGate: copyGate,
+		Encoder:  encoder,
 		Align: &attention.SoftAlign{
 			Attentor:   attentor,
 			Decoder:    decoderBlock,
 			InCombiner: inComb,
-			InitQuery:  anydiff.NewVar(c.MakeVector(querySize)),
+			InitQuery:  anydiff.NewVar(c.MakeVector(cfg.QuerySize)),
 		},
 		Output: anynet.Net{
-			anynet.NewFC(c, querySize, CharCount),
+			anynet.NewFC(c, cfg.QuerySize, CharCount),
 			anynet.LogSoftmax,
 		},
 	}
@@ -103,7 +170,7 @@ func NewNetwork(c anyvec.Creator) *Network {
 // Parameters gets the parameters of the network.
 func (n *Network) Parameters() []*anydiff.Var {
 	var res []*anydiff.Var
-	for _, p := range []anynet.Parameterizer{n.Encoder, n.Align, n.Output} {
+	for _, p := range []anynet.Parameterizer{n.Encoder, n.Align, n.Output, n.CopyGate} {
 		res = append(res, p.Parameters()...)
 	}
 	return res
@@ -117,37 +184,175 @@ func (n *Network) SerializerType() string {
 
 // Serialize attempts to serialize the Network.
 func (n *Network) Serialize() ([]byte, error) {
-	return serializer.SerializeAny(n.Encoder, n.Align, n.Output)
+	cfgData, err := json.Marshal(n.Config)
+	if err != nil {
+		return nil, err
+	}
+	if n.Config.Copy {
+		return serializer.SerializeAny(n.Encoder, n.Align, n.Output,
+			serializer.Bytes(cfgData), n.CopyGate)
+	}
+	return serializer.SerializeAny(n.Encoder, n.Align, n.Output,
+		serializer.Bytes(cfgData))
 }
 
 // Query runs a query against this Network.
-func (n *Network) Query(q string) string {
-	sample := Sample{Query: q}
-	inSeq := anyseq.ConstSeqList(n.creator(), [][]anyvec.Vector{sample.InputSequence()})
-	enc := n.Encoder.Apply(inSeq)
+//
+// If the query contains an unsupported character, an
+// *InvalidRuneError is returned.
+func (n *Network) Query(q string) (string, error) {
+	res, err := n.QueryBatch([]string{q})
+	if err != nil {
+		return "", err
+	}
+	return res[0], nil
+}
+
+// QueryBatch runs a batch of queries against this Network
+// at once.
+// The responses are returned in the same order as the
+// queries.
+//
+// If any query contains an unsupported character, an
+// *InvalidRuneError is returned.
+func (n *Network) QueryBatch(qs []string) ([]string, error) {
+	if len(qs) == 0 {
+		return []string{}, nil
+	} else if n.CopyGate != nil {
+		return n.queryEach(qs)
+	}
+
+	var inputs [][]anyvec.Vector
+	for _, q := range qs {
+		sample := Sample{Query: q}
+		inSeq, err := sample.InputSequence()
+		if err != nil {
+			return nil, err
+		}
+		inputs = append(inputs, inSeq)
+	}
+	enc := n.Encoder.Apply(anyseq.ConstSeqList(n.creator(), inputs))
 	b := anyrnn.Stack{
 		n.Align.Block(enc),
 		&anyrnn.LayerBlock{Layer: n.Output},
 	}
-	state := b.Start(1)
+	state := b.Start(len(qs))
 
-	var lastChar rune
-	var res string
+	res := make([]string, len(qs))
+	lastChars := make([]rune, len(qs))
+	present := make(anyrnn.PresentMap, len(qs))
+	for i := range present {
+		present[i] = true
+	}
 
 	for {
-		result := b.Step(state, oneHotVector(lastChar))
-		state = result.State()
-		nextIdx := anyvec.MaxIndex(result.Output())
-		lastChar = rune(nextIdx)
-		if lastChar == 0 || len(res) >= maxResponseLen {
+		var inVecs []anyvec.Vector
+		for i, p := range present {
+			if p {
+				inVecs = append(inVecs, oneHotVector(lastChars[i]))
+			}
+		}
+		if len(inVecs) == 0 {
 			break
 		}
-		res += string(lastChar)
+		result := b.Step(state, n.creator().Concat(inVecs...))
+		outputs := vectorFloats(result.Output())
+
+		nextPresent := append(anyrnn.PresentMap{}, present...)
+		var outIdx int
+		for i, p := range present {
+			if !p {
+				continue
+			}
+			logProbs := outputs[outIdx*CharCount : (outIdx+1)*CharCount]
+			outIdx++
+			lastChars[i] = rune(maxIndex(logProbs))
+			if lastChars[i] == Terminator || len(res[i]) >= maxResponseLen {
+				nextPresent[i] = false
+			} else {
+				res[i] += string(lastChars[i])
+			}
+		}
+		state = result.State().Reduce(nextPresent)
+		present = nextPresent
 	}
 
-	return res
+	return res, nil
+}
+
+// LogProbs applies the network to a batch of queries
+// while feeding decIn to the decoder, producing the log
+// probabilities for each character of the responses.
+func (n *Network) LogProbs(encIn, decIn anyseq.Seq) anyseq.Seq {
+	if n.CopyGate != nil {
+		return n.copyLogProbs(encIn, decIn)
+	}
+	enc := n.Encoder.Apply(encIn)
+	return anyseq.Pool(enc, func(enc anyseq.Seq) anyseq.Seq {
+		block := n.Align.Block(enc)
+		return anyseq.Map(anyrnn.Map(decIn, block), n.Output.Apply)
+	})
+}
+
+// encode applies the encoder to a query.
+func (n *Network) encode(q string) (anyseq.Seq, error) {
+	sample := Sample{Query: q}
+	inSeq, err := sample.InputSequence()
+	if err != nil {
+		return nil, err
+	}
+	return n.Encoder.Apply(anyseq.ConstSeqList(n.creator(), [][]anyvec.Vector{inSeq})), nil
 }
 
 func (n *Network) creator() anyvec.Creator {
 	return n.Parameters()[0].Vector.Creator()
 }
+
+// cellStack creates a stack of recurrent layers.
+// If inScale is non-nil, the inputs to the first layer are
+// initially scaled by it.
+func cellStack(c anyvec.Creator, cell string, inSize, hiddenSize, outSize,
+	layers int, inScale anyvec.Numeric) anyrnn.Stack {
+	var res anyrnn.Stack
+	for i := 0; i < layers; i++ {
+		layerIn, layerOut := hiddenSize, hiddenSize
+		if i == 0 {
+			layerIn = inSize
+		}
+		if i == layers-1 {
+			layerOut = outSize
+		}
+		switch cell {
+		case "", LSTMCell:
+			lstm := anyrnn.NewLSTM(c, layerIn, layerOut)
+			if i == 0 && inScale != nil {
+				lstm.ScaleInWeights(inScale)
+			}
+			res = append(res, lstm)
+		case GRUCell, VanillaCell:
+			if i == 0 && inScale != nil {
+				res = append(res, &anyrnn.LayerBlock{Layer: inputScaler(c, layerIn, inScale)})
+			}
+			if cell == GRUCell {
+				res = append(res, anyrnn.NewGRU(c, layerIn, layerOut))
+			} else {
+				res = append(res, anyrnn.NewVanilla(c, layerIn, layerOut, anynet.Tanh))
+			}
+		default:
+			panic("unknown cell type: " + cell)
+		}
+	}
+	return res
+}
+
+// inputScaler creates a layer which scales its inputs,
+// serving the same purpose as LSTM.ScaleInWeights for cells
+// which do not support it.
+func inputScaler(c anyvec.Creator, size int, scale anyvec.Numeric) *anynet.Affine {
+	scalers := c.MakeVector(size)
+	scalers.AddScalar(scale)
+	return &anynet.Affine{
+		Scalers: anydiff.NewVar(scalers),
+		Biases:  anydiff.NewVar(c.MakeVector(size)),
+	}
+}
@@ -0,0 +1,109 @@
+package algebrain
+
+import "fmt"
+
+// These are the recurrent cell types which a Network can
+// use.
+const (
+	LSTMCell    = "lstm"
+	GRUCell     = "gru"
+	VanillaCell = "rnn"
+)
+
+// A NetworkConfig specifies the architecture of a Network.
+type NetworkConfig struct {
+	// EncoderCell is the type of recurrent cell used in the
+	// encoder.
+	// If empty, LSTMCell is used.
+	EncoderCell string
+
+	// EncoderLayers is the number of recurrent layers in
+	// each direction of the bidirectional encoder.
+	EncoderLayers int
+
+	// EncoderHidden is the output size of every encoder
+	// layer except for the last one.
+	EncoderHidden int
+
+	// EncodedSize is the size of each encoded vector.
+	EncodedSize int
+
+	// DecoderCell is the type of recurrent cell used in the
+	// attention decoder.
+	// If empty, LSTMCell is used.
+	DecoderCell string
+
+	// DecoderLayers is the number of recurrent layers in
+	// the attention decoder.
+	DecoderLayers int
+
+	// DecoderHidden is the output size of every decoder
+	// layer except for the last one.
+	DecoderHidden int
+
+	// QuerySize is the output size of the decoder, which is
+	// also the size of the attention query.
+	QuerySize int
+
+	// AttentionSize is the hidden size of the network which
+	// computes attention weights.
+	AttentionSize int
+
+	// InScale scales the initial weights which are applied
+	// to one-hot input characters.
+	InScale float64
+
+	// Copy enables a pointer-generator output, where the
+	// network can copy query characters according to its
+	// attention weights instead of generating them.
+	Copy bool
+}
+
+// DefaultNetworkConfig creates the configuration used by
+// NewNetwork.
+func DefaultNetworkConfig() *NetworkConfig {
+	return &NetworkConfig{
+		EncoderCell:   LSTMCell,
+		EncoderLayers: 2,
+		EncoderHidden: 0x100,
+		EncodedSize:   0x40,
+		DecoderCell:   LSTMCell,
+		DecoderLayers: 2,
+		DecoderHidden: 0x100,
+		QuerySize:     0x80,
+		AttentionSize: 0x80,
+		InScale:       16,
+	}
+}
+
+// Validate checks that the configuration describes a
+// Network which can be created.
+func (n *NetworkConfig) Validate() error {
+	for _, cell := range []string{n.EncoderCell, n.DecoderCell} {
+		switch cell {
+		case "", LSTMCell, GRUCell, VanillaCell:
+		default:
+			return fmt.Errorf("network config: unknown cell type %q", cell)
+		}
+	}
+	if n.EncoderLayers < 1 || n.DecoderLayers < 1 {
+		return fmt.Errorf("network config: need at least one encoder and decoder "+
+			"layer (got %d and %d)", n.EncoderLayers, n.DecoderLayers)
+	}
+	sizes := []struct {
+		Name string
+		Size int
+	}{
+		{"encoder hidden size", n.EncoderHidden},
+		{"encoded size", n.EncodedSize},
+		{"decoder hidden size", n.DecoderHidden},
+		{"query size", n.QuerySize},
+		{"attention size", n.AttentionSize},
+	}
+	for _, size := range sizes {
+		if size.Size < 1 {
+			return fmt.Errorf("network config: invalid %s %d", size.Name, size.Size)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,35 @@
+package algebrain
+
+import "testing"
+
+func TestNetworkConfigValidate(t *testing.T) {
+	if err := DefaultNetworkConfig().Validate(); err != nil {
+		t.Fatal(err)
+	}
+	for _, cell := range []string{"", LSTMCell, GRUCell, VanillaCell} {
+		c := DefaultNetworkConfig()
+		c.EncoderCell = cell
+		c.DecoderCell = cell
+		if err := c.Validate(); err != nil {
+			t.Errorf("cell %q: %v", cell, err)
+		}
+	}
+	invalid := []func(c *NetworkConfig){
+		func(c *NetworkConfig) { c.EncoderCell = "foo" },
+		func(c *NetworkConfig) { c.DecoderCell = "LSTM" },
+		func(c *NetworkConfig) { c.EncoderLayers = 0 },
+		func(c *NetworkConfig) { c.DecoderLayers = -1 },
+		func(c *NetworkConfig) { c.EncoderHidden = 0 },
+		func(c *NetworkConfig) { c.EncodedSize = -3 },
+		func(c *NetworkConfig) { c.DecoderHidden = 0 },
+		func(c *NetworkConfig) { c.QuerySize = 0 },
+		func(c *NetworkConfig) { c.AttentionSize = 0 },
+	}
+	for i, f := range invalid {
+		c := DefaultNetworkConfig()
+		f(c)
+		if err := c.Validate(); err == nil {
+			t.Errorf("case %d: expected error for %+v", i, *c)
+		}
+	}
+}
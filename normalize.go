@@ -0,0 +1,143 @@
+package algebrain
+
+import (
+	"bytes"
+	"unicode"
+)
+
+var normalizedRunes = map[rune]string{
+	'×':      "*",
+	'·':      "*",
+	'÷':      "/",
+	'−':      "-",
+	'–':      "-",
+	'π':      "pi",
+	'²':      "^2",
+	'³':      "^3",
+	'\u00a0': " ",
+}
+
+// NormalizeQuery replaces common Unicode math symbols in a
+// query with the ASCII forms that a Network understands.
+//
+// For example, "×" becomes "*", "π" becomes "pi", and "x²"
+// becomes "x^2".
+// A square root like "√x", "√(x+1)", or "√√x" is rewritten
+// as a power, like "(x^0.5)" or "((x+1)^0.5)".
+// Since implicit multiplication is not supported, a "*"
+// is inserted between such a term and an adjacent one, so
+// "2π" becomes "2*pi" and "√2x" becomes "(2^0.5)*x".
+//
+// Characters without an ASCII equivalent, including a "√"
+// which is not followed by an operand, are left alone, so
+// the result should still be checked with ValidateString.
+func NormalizeQuery(q string) string {
+	var res bytes.Buffer
+	runes := []rune(q)
+	var afterTerm bool
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		if afterTerm && (isAtomRune(r) || r == '(') {
+			res.WriteByte('*')
+		}
+		afterTerm = false
+		if r == '√' || r == 'π' {
+			term, end := normalizeTerm(runes, i)
+			if term == "" {
+				res.WriteRune(r)
+				continue
+			}
+			if out := res.Bytes(); len(out) > 0 {
+				if last := rune(out[len(out)-1]); isAtomRune(last) || last == ')' {
+					res.WriteByte('*')
+				}
+			}
+			res.WriteString(term)
+			afterTerm = true
+			i = end - 1
+		} else if s, ok := normalizedRunes[r]; ok {
+			res.WriteString(s)
+			afterTerm = r == '²' || r == '³'
+		} else {
+			res.WriteRune(r)
+		}
+	}
+	return res.String()
+}
+
+// normalizeTerm normalizes the term starting at index
+// start, which may be a root, "π", a parenthesized
+// expression, a number, or a name with optional arguments.
+//
+// It returns the normalized term and the index after it.
+// If there is no term at start, it returns "".
+func normalizeTerm(runes []rune, start int) (string, int) {
+	if start >= len(runes) {
+		return "", start
+	}
+	r := runes[start]
+	switch {
+	case r == '√':
+		operand, end := normalizeTerm(runes, start+1)
+		if operand == "" {
+			return "", start
+		}
+		return "(" + operand + "^0.5)", end
+	case r == 'π':
+		return "pi", start + 1
+	case r == '(':
+		end := matchingParen(runes, start)
+		if end < 0 {
+			return "", start
+		}
+		return "(" + NormalizeQuery(string(runes[start+1:end])) + ")", end + 1
+	case isNumberRune(r):
+		end := start + 1
+		for end < len(runes) && isNumberRune(runes[end]) {
+			end++
+		}
+		return string(runes[start:end]), end
+	case isAtomRune(r) && unicode.IsLetter(r):
+		end := start + 1
+		for end < len(runes) && isAtomRune(runes[end]) && runes[end] != '.' {
+			end++
+		}
+		name := string(runes[start:end])
+		if args, argsEnd := normalizeTerm(runes, end); argsEnd > end && runes[end] == '(' {
+			return name + args, argsEnd
+		}
+		return name, end
+	}
+	return "", start
+}
+
+// matchingParen finds the index of the parenthesis which
+// closes one at index start.
+// It returns -1 if there is no opening parenthesis at
+// start, or if it is never closed.
+func matchingParen(runes []rune, start int) int {
+	if start >= len(runes) || runes[start] != '(' {
+		return -1
+	}
+	var depth int
+	for i := start; i < len(runes); i++ {
+		if runes[i] == '(' {
+			depth++
+		} else if runes[i] == ')' {
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+func isAtomRune(r rune) bool {
+	return r < CharCount && (unicode.IsLetter(r) || unicode.IsDigit(r) ||
+		r == '.' || r == '_')
+}
+
+func isNumberRune(r rune) bool {
+	return (r >= '0' && r <= '9') || r == '.'
+}
@@ -0,0 +1,59 @@
+package algebrain
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/unixpickle/algebrain/mathexpr"
+)
+
+func TestNormalizeQuery(t *testing.T) {
+	cases := map[string]string{
+		"evaluate 3×4":             "evaluate 3*4",
+		"evaluate 3·4":             "evaluate 3*4",
+		"evaluate 8÷2":             "evaluate 8/2",
+		"evaluate 5−2":             "evaluate 5-2",
+		"evaluate 5–2":             "evaluate 5-2",
+		"evaluate 1+1":             "evaluate 1+1",
+		"scale x by π in x":        "scale x by pi in x",
+		"shift x by 2π in x":       "shift x by 2*pi in x",
+		"evaluate π2":              "evaluate pi*2",
+		"evaluate ππ":              "evaluate pi*pi",
+		"shift x by 1 in x²":       "shift x by 1 in x^2",
+		"shift x by 1 in x³y":      "shift x by 1 in x^3*y",
+		"shift x by 1 in √x":       "shift x by 1 in (x^0.5)",
+		"shift x by 1 in √x1":      "shift x by 1 in (x1^0.5)",
+		"shift x by 1 in √(x+1)×2": "shift x by 1 in ((x+1)^0.5)*2",
+		"shift x by 1 in √(√x+1)":  "shift x by 1 in (((x^0.5)+1)^0.5)",
+		"shift x by 1 in √√x":      "shift x by 1 in ((x^0.5)^0.5)",
+		"shift x by 1 in √2x":      "shift x by 1 in (2^0.5)*x",
+		"shift x by 1 in 3√x":      "shift x by 1 in 3*(x^0.5)",
+		"shift x by 1 in √x√x":     "shift x by 1 in (x^0.5)*(x^0.5)",
+		"shift x by 1 in √sin(x)":  "shift x by 1 in (sin(x)^0.5)",
+		"shift x by 1 in √π":       "shift x by 1 in (pi^0.5)",
+		"evaluate √2.25":           "evaluate (2.25^0.5)",
+		"evaluate √":               "evaluate √",
+		"evaluate √ 2":             "evaluate √ 2",
+		"evaluate √-2":             "evaluate √-2",
+		"evaluate √(2":             "evaluate √(2",
+		"evaluate 2+2":             "evaluate 2+2",
+		"evaluate 2π√4":            "evaluate 2*pi*(4^0.5)",
+	}
+	for input, expected := range cases {
+		actual := NormalizeQuery(input)
+		if actual != expected {
+			t.Errorf("%q: expected %q but got %q", input, expected, actual)
+			continue
+		}
+		if ValidateString(actual) != nil {
+			continue
+		}
+		for _, prefix := range []string{"evaluate ", "shift x by 1 in "} {
+			if strings.HasPrefix(actual, prefix) {
+				if _, err := mathexpr.Parse(strings.TrimPrefix(actual, prefix)); err != nil {
+					t.Errorf("%q: cannot parse result: %v", input, err)
+				}
+			}
+		}
+	}
+}
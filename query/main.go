@@ -2,6 +2,7 @@ package main
 
 import (
 	"bytes"
+	"flag"
 	"fmt"
 	"os"
 
@@ -10,16 +11,50 @@ import (
 	"github.com/unixpickle/serializer"
 )
 
+type querier interface {
+	Query(q string) (string, error)
+}
+
 func main() {
-	if len(os.Args) != 2 {
-		essentials.Die("Usage:", os.Args[0], "<net_file>")
+	var vote bool
+	flag.BoolVar(&vote, "vote", false,
+		"use majority voting for ensembles (always used unless all models are RNNs)")
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage:", os.Args[0], "[flags] <net_file> [net_file ...]")
+		flag.PrintDefaults()
 	}
-	var net *algebrain.Network
-	if err := serializer.LoadAny(os.Args[1], &net); err != nil {
-		essentials.Die("Failed to load block:", err)
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	var net querier
+	if flag.NArg() == 1 {
+		var model algebrain.Model
+		if err := serializer.LoadAny(flag.Arg(0), &model); err != nil {
+			essentials.Die("Failed to load block:", err)
+		}
+		net = model
+	} else {
+		ensemble, err := algebrain.LoadEnsemble(flag.Args())
+		if err != nil {
+			essentials.Die("Failed to load ensemble:", err)
+		}
+		if vote {
+			ensemble.Vote = true
+		} else if ensemble.Vote {
+			fmt.Fprintln(os.Stderr, "Using majority voting since not all models are RNNs.")
+		}
+		net = ensemble
 	}
 	for {
-		fmt.Println(net.Query(readLine()))
+		res, err := net.Query(algebrain.NormalizeQuery(readLine()))
+		if err != nil {
+			fmt.Println("Error:", err)
+		} else {
+			fmt.Println(res)
+		}
 	}
 }
 
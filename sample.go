@@ -12,20 +12,42 @@ import (
 	"github.com/unixpickle/num-analysis/linalg"
 )
 
+// ResultPrefix is prepended to the numerical responses
+// produced by an EvalGenerator.
+const ResultPrefix = "Result: "
+
 // A Sample contains a query (e.g. "factorize x^2+x") and
 // the expected result (e.g. "x(x+1)").
 type Sample struct {
 	Query    string
 	Response string
+
+	// Depth is the nesting depth of the expression in the
+	// query, if known.
+	Depth int
+
+	// GeneratorName is the name of the Generator which
+	// produced the sample, if known.
+	GeneratorName string
+
+	// Seed is a math/rand seed which reproduces the sample
+	// with its Generator, if known.
+	Seed int64
 }
 
 // InputSequence generates the sample's input sequence.
-func (s *Sample) InputSequence() []anyvec.Vector {
-	res := make([]anyvec.Vector, len(s.Query))
-	for i, x := range s.Query {
-		res[i] = oneHotVector(x)
+//
+// If the query contains an unsupported character, an
+// *InvalidRuneError is returned.
+func (s *Sample) InputSequence() ([]anyvec.Vector, error) {
+	if err := ValidateString(s.Query); err != nil {
+		return nil, err
 	}
-	return res
+	var res []anyvec.Vector
+	for _, x := range s.Query {
+		res = append(res, oneHotVector(x))
+	}
+	return res, nil
 }
 
 // DecoderOutSequence is the desired output from the
@@ -50,6 +72,37 @@ func (s *Sample) DecoderInSequence() []anyvec.Vector {
 	return res
 }
 
+// An InvalidRuneError indicates that a string contains a
+// character which cannot be fed to a Network.
+type InvalidRuneError struct {
+	Rune rune
+
+	// Index is the position of the rune in the string,
+	// measured in runes.
+	Index int
+}
+
+// Error returns a human-readable error message.
+func (i *InvalidRuneError) Error() string {
+	return fmt.Sprintf("unsupported character %q at position %d", i.Rune, i.Index)
+}
+
+// ValidateString checks that every character in a string
+// can be fed to a Network.
+//
+// If the string contains an unsupported character, an
+// *InvalidRuneError is returned for the first one.
+func ValidateString(s string) error {
+	var idx int
+	for _, x := range s {
+		if x == Terminator || x >= CharCount {
+			return &InvalidRuneError{Rune: x, Index: idx}
+		}
+		idx++
+	}
+	return nil
+}
+
 // A Generator generates random Samples from a template.
 type Generator interface {
 	Generate() *Sample
@@ -69,10 +122,12 @@ func (s *ShiftGenerator) Generate() *Sample {
 	shiftVar := s.Generator.VarNames[rand.Intn(len(s.Generator.VarNames))]
 	num := generateNumber(*s.Generator)
 	query := fmt.Sprintf("shift %s by %s in %s", shiftVar, num, expr)
+	depth := mathexpr.Depth(expr)
 	output := s.shiftNode(shiftVar, num, expr).String()
 	return &Sample{
 		Query:    query,
 		Response: output,
+		Depth:    depth,
 	}
 }
 
@@ -105,10 +160,12 @@ func (s *ScaleGenerator) Generate() *Sample {
 	shiftVar := s.Generator.VarNames[rand.Intn(len(s.Generator.VarNames))]
 	num := generateNumber(*s.Generator)
 	query := fmt.Sprintf("scale %s by %s in %s", shiftVar, num, expr)
+	depth := mathexpr.Depth(expr)
 	output := s.scaleNode(shiftVar, num, expr).String()
 	return &Sample{
 		Query:    query,
 		Response: output,
+		Depth:    depth,
 	}
 }
 
@@ -142,13 +199,16 @@ type EvalGenerator struct {
 
 func (e *EvalGenerator) Generate() *Sample {
 	var expr mathexpr.Node
+	var val float64
 	for {
 		expr = e.Generator.Generate(e.MaxDepth)
 		if e.valid(expr) {
-			break
+			var err error
+			if val, err = evalArithmetic(expr, e.AllInts); err == nil {
+				break
+			}
 		}
 	}
-	val := e.evaluateExpr(expr)
 	prec := 2
 	if e.AllInts {
 		prec = 0
@@ -156,41 +216,9 @@ func (e *EvalGenerator) Generate() *Sample {
 	outStr := strconv.FormatFloat(val, 'f', prec, 64)
 	return &Sample{
 		Query:    "evaluate " + expr.String(),
-		Response: "Result: " + outStr,
-	}
-}
-
-func (e *EvalGenerator) evaluateExpr(n mathexpr.Node) float64 {
-	switch n := n.(type) {
-	case *mathexpr.BinaryOp:
-		left := e.evaluateExpr(n.Left)
-		right := e.evaluateExpr(n.Right)
-		switch n.Op {
-		case mathexpr.AddOp:
-			return left + right
-		case mathexpr.SubtractOp:
-			return left - right
-		case mathexpr.MultiplyOp:
-			return left * right
-		case mathexpr.DivideOp:
-			if e.AllInts {
-				return float64(int(left) / int(right))
-			} else {
-				return left / right
-			}
-		case mathexpr.PowOp:
-			if e.AllInts && right < 0 {
-				return 0
-			}
-			return math.Pow(left, right)
-		}
-	case *mathexpr.NegOp:
-		return -e.evaluateExpr(n.Node)
-	case mathexpr.RawNode:
-		res, _ := strconv.ParseFloat(string(n), 64)
-		return res
+		Response: ResultPrefix + outStr,
+		Depth:    mathexpr.Depth(expr),
 	}
-	panic("unsupported expression: " + n.String())
 }
 
 func (e *EvalGenerator) valid(n mathexpr.Node) bool {
@@ -201,7 +229,10 @@ func (e *EvalGenerator) valid(n mathexpr.Node) bool {
 	}
 	switch n := n.(type) {
 	case *mathexpr.BinaryOp:
-		right := e.evaluateExpr(n.Right)
+		right, err := evalArithmetic(n.Right, e.AllInts)
+		if err != nil {
+			return false
+		}
 		if (right == 0 || !e.UseDiv) && n.Op == mathexpr.DivideOp {
 			return false
 		}
@@ -212,6 +243,60 @@ func (e *EvalGenerator) valid(n mathexpr.Node) bool {
 	return true
 }
 
+// evalArithmetic evaluates an expression made up of
+// numbers and arithmetic operators, as in the responses
+// of an EvalGenerator.
+//
+// If allInts is set, the operands of divisions are
+// truncated to integers, and powers with negative
+// exponents evaluate to zero.
+func evalArithmetic(n mathexpr.Node, allInts bool) (float64, error) {
+	switch n := n.(type) {
+	case *mathexpr.BinaryOp:
+		left, err := evalArithmetic(n.Left, allInts)
+		if err != nil {
+			return 0, err
+		}
+		right, err := evalArithmetic(n.Right, allInts)
+		if err != nil {
+			return 0, err
+		}
+		switch n.Op {
+		case mathexpr.AddOp:
+			return left + right, nil
+		case mathexpr.SubtractOp:
+			return left - right, nil
+		case mathexpr.MultiplyOp:
+			return left * right, nil
+		case mathexpr.DivideOp:
+			if allInts {
+				if int(right) == 0 {
+					return 0, &mathexpr.DomainError{Expr: n.String(), Msg: "division by zero"}
+				}
+				return float64(int(left) / int(right)), nil
+			} else if right == 0 {
+				return 0, &mathexpr.DomainError{Expr: n.String(), Msg: "division by zero"}
+			}
+			return left / right, nil
+		case mathexpr.PowOp:
+			if allInts && right < 0 {
+				return 0, nil
+			}
+			return math.Pow(left, right), nil
+		}
+	case *mathexpr.NegOp:
+		x, err := evalArithmetic(n.Node, allInts)
+		return -x, err
+	case mathexpr.RawNode:
+		res, err := strconv.ParseFloat(string(n), 64)
+		if err != nil {
+			return 0, fmt.Errorf("evaluate %s: not a number", n)
+		}
+		return res, nil
+	}
+	return 0, fmt.Errorf("evaluate %s: unsupported expression", n)
+}
+
 func generateNumber(g mathexpr.Generator) mathexpr.RawNode {
 	g.VarNames = nil
 	g.ConstNames = nil
@@ -0,0 +1,41 @@
+package algebrain
+
+import "testing"
+
+func TestValidateString(t *testing.T) {
+	if err := ValidateString("scale x by 2 in (x+1)^0.5"); err != nil {
+		t.Error(err)
+	}
+	if err := ValidateString(""); err != nil {
+		t.Error(err)
+	}
+	cases := []struct {
+		Input string
+		Rune  rune
+		Index int
+	}{
+		{"π", 'π', 0},
+		{"2×3", '×', 1},
+		{"x²+y²", '²', 1},
+		{"√x+√y", '√', 0},
+		{"πx×", 'π', 0},
+		{"xy·z", '·', 2},
+		{"x\x00y", Terminator, 1},
+	}
+	for _, c := range cases {
+		err := ValidateString(c.Input)
+		runeErr, ok := err.(*InvalidRuneError)
+		if !ok {
+			t.Errorf("%q: expected *InvalidRuneError but got %v", c.Input, err)
+		} else if runeErr.Rune != c.Rune || runeErr.Index != c.Index {
+			t.Errorf("%q: expected %q at %d but got %q at %d", c.Input, c.Rune, c.Index,
+				runeErr.Rune, runeErr.Index)
+		}
+	}
+
+	err := ValidateString("12÷4")
+	expected := "unsupported character '÷' at position 2"
+	if err == nil || err.Error() != expected {
+		t.Errorf("expected error %q but got %v", expected, err)
+	}
+}
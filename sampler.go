@@ -0,0 +1,134 @@
+package algebrain
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+)
+
+// A Sampler chooses characters at random from the output
+// distribution of a Network.
+type Sampler struct {
+	// Temperature divides the log probabilities before they
+	// are re-normalized.
+	// If 0, a temperature of 1 is used.
+	// Negative temperatures are invalid.
+	Temperature float64
+
+	// TopK, if non-zero, restricts sampling to the TopK most
+	// likely characters.
+	TopK int
+
+	// TopP, if non-zero, restricts sampling to the smallest
+	// set of most likely characters whose total probability
+	// is at least TopP.
+	// It must be between 0 and 1.
+	TopP float64
+
+	// Rand is the source of randomness.
+	// If nil, the math/rand package is used.
+	Rand *rand.Rand
+}
+
+// Validate checks that the sampling parameters are in
+// range.
+func (s *Sampler) Validate() error {
+	if s.Temperature < 0 {
+		return fmt.Errorf("sampler: invalid temperature %f", s.Temperature)
+	}
+	if s.TopK < 0 {
+		return fmt.Errorf("sampler: invalid top-k %d", s.TopK)
+	}
+	if s.TopP < 0 || s.TopP > 1 {
+		return fmt.Errorf("sampler: invalid top-p %f", s.TopP)
+	}
+	return nil
+}
+
+// Sample chooses an index given a list of log
+// probabilities.
+//
+// The Sampler must be valid, as checked by Validate.
+func (s *Sampler) Sample(logProbs []float64) int {
+	temp := s.Temperature
+	if temp == 0 {
+		temp = 1
+	}
+	indices := topIndices(logProbs, len(logProbs))
+	if s.TopK != 0 && s.TopK < len(indices) {
+		indices = indices[:s.TopK]
+	}
+
+	probs := make([]float64, len(indices))
+	maxLogProb := logProbs[indices[0]]
+	var total float64
+	for i, idx := range indices {
+		probs[i] = math.Exp((logProbs[idx] - maxLogProb) / temp)
+		total += probs[i]
+	}
+	for i := range probs {
+		probs[i] /= total
+	}
+	total = 1
+
+	if s.TopP != 0 {
+		var cumulative float64
+		for i, p := range probs {
+			cumulative += p
+			if cumulative >= s.TopP {
+				probs = probs[:i+1]
+				total = cumulative
+				break
+			}
+		}
+	}
+
+	x := s.float64() * total
+	for i, p := range probs {
+		x -= p
+		if x < 0 {
+			return indices[i]
+		}
+	}
+	return indices[len(probs)-1]
+}
+
+func (s *Sampler) float64() float64 {
+	if s.Rand == nil {
+		return rand.Float64()
+	}
+	return s.Rand.Float64()
+}
+
+// QuerySample runs a query, sampling each character of the
+// response with s.
+//
+// The resulting Candidate's LogProb is computed under the
+// unmodified distribution of the Network.
+// An error is returned if s is not valid.
+func (n *Network) QuerySample(q string, s *Sampler) (*Candidate, error) {
+	if err := s.Validate(); err != nil {
+		return nil, err
+	}
+	d, err := n.decoder(q)
+	if err != nil {
+		return nil, err
+	}
+	state := d.Start()
+
+	var lastChar rune
+	res := &Candidate{}
+
+	for len(res.Response) < maxResponseLen {
+		var logProbs []float64
+		state, logProbs, _ = d.Step(state, lastChar)
+		lastChar = rune(s.Sample(logProbs))
+		res.LogProb += logProbs[lastChar]
+		if lastChar == Terminator {
+			break
+		}
+		res.Response += string(lastChar)
+	}
+
+	return res, nil
+}
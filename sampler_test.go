@@ -0,0 +1,68 @@
+package algebrain
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestSamplerSample(t *testing.T) {
+	logProbs := []float64{math.Log(0.2), math.Log(0.5), math.Log(0.05), math.Log(0.25)}
+	cases := []struct {
+		Sampler  Sampler
+		Expected []float64
+	}{
+		{Sampler{}, []float64{0.2, 0.5, 0.05, 0.25}},
+		{Sampler{Temperature: 1}, []float64{0.2, 0.5, 0.05, 0.25}},
+		{Sampler{Temperature: 0.01}, []float64{0, 1, 0, 0}},
+		{Sampler{Temperature: 1e6}, []float64{0.25, 0.25, 0.25, 0.25}},
+		{Sampler{TopK: 1}, []float64{0, 1, 0, 0}},
+		{Sampler{TopK: 2}, []float64{0, 0.5 / 0.75, 0, 0.25 / 0.75}},
+		{Sampler{TopK: 10}, []float64{0.2, 0.5, 0.05, 0.25}},
+		{Sampler{TopP: 0.4}, []float64{0, 1, 0, 0}},
+		{Sampler{TopP: 0.9}, []float64{0.2 / 0.95, 0.5 / 0.95, 0, 0.25 / 0.95}},
+		{Sampler{TopP: 1}, []float64{0.2, 0.5, 0.05, 0.25}},
+		{Sampler{TopK: 3, TopP: 0.6}, []float64{0, 0.5 / 0.75, 0, 0.25 / 0.75}},
+	}
+	const numSamples = 20000
+	for i, c := range cases {
+		c.Sampler.Rand = rand.New(rand.NewSource(1337))
+		counts := make([]float64, len(logProbs))
+		for j := 0; j < numSamples; j++ {
+			counts[c.Sampler.Sample(logProbs)]++
+		}
+		for j, expected := range c.Expected {
+			actual := counts[j] / numSamples
+			if expected == 0 && actual != 0 {
+				t.Errorf("case %d: index %d should never be sampled", i, j)
+			} else if math.Abs(actual-expected) > 0.02 {
+				t.Errorf("case %d: index %d: expected frequency %f but got %f", i, j,
+					expected, actual)
+			}
+		}
+	}
+}
+
+func TestSamplerValidate(t *testing.T) {
+	valid := []Sampler{
+		{},
+		{Temperature: 0.5, TopK: 3, TopP: 0.9},
+		{TopP: 1},
+	}
+	for _, s := range valid {
+		if err := s.Validate(); err != nil {
+			t.Errorf("%+v: %v", s, err)
+		}
+	}
+	invalid := []Sampler{
+		{Temperature: -1},
+		{TopK: -1},
+		{TopP: -0.1},
+		{TopP: 1.5},
+	}
+	for _, s := range invalid {
+		if err := s.Validate(); err == nil {
+			t.Errorf("expected error for %+v", s)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package algebrain
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/unixpickle/serializer"
+)
+
+// SaveAtomic is like serializer.SaveAny, except that the
+// file is replaced atomically.
+//
+// If the process is killed mid-save, the file at path
+// keeps its previous contents.
+func SaveAtomic(path string, objs ...interface{}) error {
+	data, err := serializer.SerializeAny(objs...)
+	if err != nil {
+		return err
+	}
+	return WriteFileAtomic(path, data)
+}
+
+// WriteFileAtomic writes data to a temporary file and then
+// renames it to path.
+func WriteFileAtomic(path string, data []byte) error {
+	f, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	err = writeAndClose(f, data)
+	if err == nil {
+		err = os.Rename(f.Name(), path)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+	}
+	return err
+}
+
+// writeAndClose writes data to f and flushes it to disk
+// before closing f.
+func writeAndClose(f *os.File, data []byte) error {
+	defer f.Close()
+	if err := f.Chmod(0644); err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		return err
+	}
+	return f.Close()
+}
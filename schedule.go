@@ -0,0 +1,152 @@
+package algebrain
+
+import (
+	"fmt"
+	"math"
+)
+
+// A Schedule computes a learning rate as a function of
+// the number of training iterations so far.
+type Schedule interface {
+	Rate(iter int) float64
+}
+
+// A ScheduledRater is an anysgd.Rater which follows a
+// Schedule.
+//
+// Rather than using the epoch passed by anysgd, the rate
+// is computed from Iter, which should be kept up to date
+// by the training loop.
+// This way, a resumed run can continue from the same rate
+// by restoring Iter.
+type ScheduledRater struct {
+	Schedule Schedule
+	Iter     int
+}
+
+// Rate returns the rate for the current iteration.
+func (s *ScheduledRater) Rate(epoch float64) float64 {
+	return s.Schedule.Rate(s.Iter)
+}
+
+// ConstSchedule is a Schedule with a constant rate.
+type ConstSchedule float64
+
+// Rate returns the constant rate.
+func (c ConstSchedule) Rate(iter int) float64 {
+	return float64(c)
+}
+
+// A WarmupSchedule linearly increases the rate of another
+// Schedule from zero over the first Iters iterations.
+type WarmupSchedule struct {
+	Schedule Schedule
+	Iters    int
+}
+
+// Rate returns the warmed-up rate.
+func (w *WarmupSchedule) Rate(iter int) float64 {
+	res := w.Schedule.Rate(iter)
+	if iter < w.Iters {
+		res *= float64(iter+1) / float64(w.Iters)
+	}
+	return res
+}
+
+// A StepSchedule multiplies the rate by Factor every
+// Interval iterations.
+type StepSchedule struct {
+	Initial  float64
+	Interval int
+	Factor   float64
+}
+
+// Rate returns the decayed rate.
+func (s *StepSchedule) Rate(iter int) float64 {
+	return s.Initial * math.Pow(s.Factor, float64(iter/s.Interval))
+}
+
+// A CosineSchedule anneals the rate from Initial to Final
+// along a half cosine wave over Iters iterations, and then
+// stays at Final.
+type CosineSchedule struct {
+	Initial float64
+	Final   float64
+	Iters   int
+}
+
+// Rate returns the annealed rate.
+func (c *CosineSchedule) Rate(iter int) float64 {
+	frac := math.Min(1, float64(iter)/float64(c.Iters))
+	return c.Final + (c.Initial-c.Final)*0.5*(1+math.Cos(math.Pi*frac))
+}
+
+// An InvSqrtSchedule keeps the rate at Initial for Offset
+// iterations, and then decays it proportionally to the
+// inverse square root of the iteration.
+type InvSqrtSchedule struct {
+	Initial float64
+	Offset  int
+}
+
+// Rate returns the decayed rate.
+func (i *InvSqrtSchedule) Rate(iter int) float64 {
+	if iter <= i.Offset {
+		return i.Initial
+	}
+	return i.Initial * math.Sqrt(float64(i.Offset)/float64(iter))
+}
+
+// A ScheduleConfig describes a Schedule, for example one
+// specified by command-line flags.
+type ScheduleConfig struct {
+	// Kind is "const", "step", "cosine", or "invsqrt".
+	Kind string
+
+	// Rate is the initial rate, after warmup.
+	Rate float64
+
+	// Warmup is the number of linear warmup iterations.
+	Warmup int
+
+	// Interval and Factor configure "step" schedules.
+	Interval int
+	Factor   float64
+
+	// Iters and FinalRate configure "cosine" schedules.
+	Iters     int
+	FinalRate float64
+
+	// Offset configures "invsqrt" schedules.
+	Offset int
+}
+
+// Schedule creates the described Schedule.
+func (s *ScheduleConfig) Schedule() (Schedule, error) {
+	var res Schedule
+	switch s.Kind {
+	case "const":
+		res = ConstSchedule(s.Rate)
+	case "step":
+		if s.Interval < 1 {
+			return nil, fmt.Errorf("step schedule: invalid interval %d", s.Interval)
+		}
+		res = &StepSchedule{Initial: s.Rate, Interval: s.Interval, Factor: s.Factor}
+	case "cosine":
+		if s.Iters < 1 {
+			return nil, fmt.Errorf("cosine schedule: invalid iterations %d", s.Iters)
+		}
+		res = &CosineSchedule{Initial: s.Rate, Final: s.FinalRate, Iters: s.Iters}
+	case "invsqrt":
+		if s.Offset < 1 {
+			return nil, fmt.Errorf("invsqrt schedule: invalid offset %d", s.Offset)
+		}
+		res = &InvSqrtSchedule{Initial: s.Rate, Offset: s.Offset}
+	default:
+		return nil, fmt.Errorf("unknown schedule: %s", s.Kind)
+	}
+	if s.Warmup > 0 {
+		res = &WarmupSchedule{Schedule: res, Iters: s.Warmup}
+	}
+	return res, nil
+}
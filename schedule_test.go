@@ -0,0 +1,94 @@
+package algebrain
+
+import (
+	"math"
+	"testing"
+)
+
+func TestWarmupSchedule(t *testing.T) {
+	s := &WarmupSchedule{Schedule: ConstSchedule(2), Iters: 4}
+	expected := []float64{0.5, 1, 1.5, 2, 2, 2}
+	for i, x := range expected {
+		if a := s.Rate(i); math.Abs(a-x) > 1e-8 {
+			t.Errorf("iter %d: expected %f but got %f", i, x, a)
+		}
+	}
+}
+
+func TestStepSchedule(t *testing.T) {
+	s := &StepSchedule{Initial: 1, Interval: 10, Factor: 0.5}
+	expected := map[int]float64{0: 1, 9: 1, 10: 0.5, 19: 0.5, 20: 0.25, 35: 0.125}
+	for i, x := range expected {
+		if a := s.Rate(i); math.Abs(a-x) > 1e-8 {
+			t.Errorf("iter %d: expected %f but got %f", i, x, a)
+		}
+	}
+}
+
+func TestCosineSchedule(t *testing.T) {
+	s := &CosineSchedule{Initial: 1, Final: 0.1, Iters: 100}
+	expected := map[int]float64{0: 1, 50: 0.55, 100: 0.1, 150: 0.1}
+	for i, x := range expected {
+		if a := s.Rate(i); math.Abs(a-x) > 1e-8 {
+			t.Errorf("iter %d: expected %f but got %f", i, x, a)
+		}
+	}
+	for i := 1; i <= 100; i++ {
+		if s.Rate(i) > s.Rate(i-1) {
+			t.Fatalf("rate increased at iter %d", i)
+		}
+	}
+}
+
+func TestInvSqrtSchedule(t *testing.T) {
+	s := &InvSqrtSchedule{Initial: 2, Offset: 100}
+	expected := map[int]float64{0: 2, 100: 2, 400: 1, 10000: 0.2}
+	for i, x := range expected {
+		if a := s.Rate(i); math.Abs(a-x) > 1e-8 {
+			t.Errorf("iter %d: expected %f but got %f", i, x, a)
+		}
+	}
+	if diff := s.Rate(100) - s.Rate(101); diff < 0 || diff > 0.01 {
+		t.Errorf("rate is discontinuous at offset: %f then %f", s.Rate(100), s.Rate(101))
+	}
+}
+
+func TestScheduleConfig(t *testing.T) {
+	valid := []*ScheduleConfig{
+		{Kind: "const", Rate: 0.1},
+		{Kind: "step", Rate: 0.1, Interval: 10, Factor: 0.5},
+		{Kind: "cosine", Rate: 0.1, Iters: 100},
+		{Kind: "invsqrt", Rate: 0.1, Offset: 100, Warmup: 10},
+	}
+	for _, c := range valid {
+		s, err := c.Schedule()
+		if err != nil {
+			t.Errorf("%s: %v", c.Kind, err)
+			continue
+		}
+		if a := s.Rate(c.Warmup); math.Abs(a-c.Rate) > 1e-8 {
+			t.Errorf("%s: expected rate %f but got %f", c.Kind, c.Rate, a)
+		}
+	}
+
+	s, err := (&ScheduleConfig{Kind: "const", Rate: 1, Warmup: 10}).Schedule()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := s.(*WarmupSchedule); !ok || s.Rate(0) >= 1 {
+		t.Errorf("expected warmup but got %T with initial rate %f", s, s.Rate(0))
+	}
+
+	invalid := []*ScheduleConfig{
+		{Kind: "step", Rate: 0.1, Factor: 0.5},
+		{Kind: "cosine", Rate: 0.1, Iters: -1},
+		{Kind: "invsqrt", Rate: 0.1},
+		{Kind: "linear", Rate: 0.1},
+		{Rate: 0.1},
+	}
+	for _, c := range invalid {
+		if _, err := c.Schedule(); err == nil {
+			t.Errorf("expected error for %+v", *c)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package algebrain
+
+import (
+	"github.com/unixpickle/anydiff/anyseq"
+	"github.com/unixpickle/anyvec"
+)
+
+// A ResponseScore describes how likely a Network thinks a
+// response is.
+type ResponseScore struct {
+	// Total is the log probability of the entire response,
+	// including the terminator.
+	Total float64
+
+	// PerChar stores the log probability of each character
+	// in the response, followed by the log probability of
+	// the terminator.
+	PerChar []float64
+}
+
+// LeastLikely returns the index in PerChar of the
+// character which the network is least sure about.
+// An index of len(PerChar)-1 refers to the terminator.
+func (r *ResponseScore) LeastLikely() int {
+	var res int
+	for i, x := range r.PerChar {
+		if x < r.PerChar[res] {
+			res = i
+		}
+	}
+	return res
+}
+
+// Score computes the log probability of a response to a
+// query by feeding the response to the decoder, exactly
+// as is done during training.
+//
+// If either string contains an unsupported character, an
+// *InvalidRuneError is returned.
+func (n *Network) Score(q, response string) (*ResponseScore, error) {
+	sample := &Sample{Query: q, Response: response}
+	inSeq, err := sample.InputSequence()
+	if err != nil {
+		return nil, err
+	}
+	if err := ValidateString(response); err != nil {
+		return nil, err
+	}
+	c := n.creator()
+	encIn := anyseq.ConstSeqList(c, [][]anyvec.Vector{inSeq})
+	decIn := anyseq.ConstSeqList(c, [][]anyvec.Vector{sample.DecoderInSequence()})
+	targets := sample.DecoderOutSequence()
+
+	res := &ResponseScore{}
+	for i, batch := range n.LogProbs(encIn, decIn).Output() {
+		logProb := c.Float64(batch.Packed.Dot(targets[i]))
+		res.PerChar = append(res.PerChar, logProb)
+		res.Total += logProb
+	}
+	return res, nil
+}
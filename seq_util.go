@@ -0,0 +1,137 @@
+package algebrain
+
+import (
+	"github.com/unixpickle/anydiff"
+	"github.com/unixpickle/anydiff/anyseq"
+	"github.com/unixpickle/anyvec"
+)
+
+// splitSeq converts a batch of sequences into a list of
+// timesteps for each sequence in the batch.
+func splitSeq(s anyseq.Seq) [][]anyvec.Vector {
+	batches := s.Output()
+	if len(batches) == 0 {
+		return nil
+	}
+	res := make([][]anyvec.Vector, len(batches[0].Present))
+	for _, batch := range batches {
+		var numPresent int
+		for _, p := range batch.Present {
+			if p {
+				numPresent++
+			}
+		}
+		size := batch.Packed.Len() / numPresent
+		var idx int
+		for i, p := range batch.Present {
+			if p {
+				res[i] = append(res[i], batch.Packed.Slice(idx*size, (idx+1)*size))
+				idx++
+			}
+		}
+	}
+	return res
+}
+
+// resSeq is an anyseq.Seq built from one row-major matrix
+// per sequence, where each row is a timestep.
+type resSeq struct {
+	creator anyvec.Creator
+	seqs    []anydiff.Res
+	lens    []int
+	cols    int
+	out     []*anyseq.Batch
+}
+
+// packSeqs creates a batch of sequences where the i-th
+// sequence has lens[i] timesteps of size cols, stored as
+// the rows of seqs[i].
+func packSeqs(c anyvec.Creator, seqs []anydiff.Res, lens []int, cols int) anyseq.Seq {
+	res := &resSeq{creator: c, seqs: seqs, lens: lens, cols: cols}
+	for t := 0; ; t++ {
+		batch := &anyseq.Batch{Present: make([]bool, len(seqs))}
+		var rows []anyvec.Vector
+		for i, seq := range seqs {
+			if t < lens[i] {
+				batch.Present[i] = true
+				rows = append(rows, seq.Output().Slice(t*cols, (t+1)*cols))
+			}
+		}
+		if len(rows) == 0 {
+			break
+		}
+		batch.Packed = c.Concat(rows...)
+		res.out = append(res.out, batch)
+	}
+	return res
+}
+
+func (r *resSeq) Creator() anyvec.Creator {
+	return r.creator
+}
+
+func (r *resSeq) Output() []*anyseq.Batch {
+	return r.out
+}
+
+func (r *resSeq) Vars() anydiff.VarSet {
+	var sets []anydiff.VarSet
+	for _, seq := range r.seqs {
+		sets = append(sets, seq.Vars())
+	}
+	return anydiff.MergeVarSets(sets...)
+}
+
+func (r *resSeq) Propagate(upstream []*anyseq.Batch, g anydiff.Grad) {
+	rows := make([][]anyvec.Vector, len(r.seqs))
+	for _, batch := range upstream {
+		var idx int
+		for i, p := range batch.Present {
+			if p {
+				row := batch.Packed.Slice(idx*r.cols, (idx+1)*r.cols)
+				rows[i] = append(rows[i], row)
+				idx++
+			}
+		}
+	}
+	for i, seq := range r.seqs {
+		seq.Propagate(r.creator.Concat(rows[i]...), g)
+	}
+}
+
+// seqRes is an anydiff.Res which concatenates the
+// timesteps of a batch containing a single sequence.
+type seqRes struct {
+	seq anyseq.Seq
+	out anyvec.Vector
+}
+
+func seqToRes(s anyseq.Seq) anydiff.Res {
+	var vecs []anyvec.Vector
+	for _, batch := range s.Output() {
+		vecs = append(vecs, batch.Packed)
+	}
+	return &seqRes{seq: s, out: s.Creator().Concat(vecs...)}
+}
+
+func (s *seqRes) Output() anyvec.Vector {
+	return s.out
+}
+
+func (s *seqRes) Vars() anydiff.VarSet {
+	return s.seq.Vars()
+}
+
+func (s *seqRes) Propagate(u anyvec.Vector, g anydiff.Grad) {
+	var upstream []*anyseq.Batch
+	var offset int
+	for _, batch := range s.seq.Output() {
+		size := batch.Packed.Len()
+		upstream = append(upstream, &anyseq.Batch{
+			Packed:  u.Slice(offset, offset+size),
+			Present: batch.Present,
+		})
+		offset += size
+	}
+	s.seq.Propagate(upstream, g)
+}
@@ -0,0 +1,70 @@
+package algebrain
+
+import (
+	"math/rand"
+
+	"github.com/unixpickle/anynet/anysgd"
+)
+
+// A StreamSampleList is an anysgd.SampleList which draws
+// fresh samples from its Generators every time it is
+// sliced, so that no two batches share a fixed pool.
+//
+// Optionally, some samples may be drawn from a replay
+// buffer of previously generated samples.
+type StreamSampleList struct {
+	// Generators are chosen uniformly at random for each
+	// new sample.
+	Generators []Generator
+
+	// EpochSize is the number of samples per epoch, as
+	// reported by Len.
+	EpochSize int
+
+	// ReplaySize is the maximum number of samples kept in
+	// the replay buffer.
+	// If 0, no replay buffer is used.
+	ReplaySize int
+
+	// ReplayFraction is the probability that a sample is
+	// drawn from the replay buffer rather than generated.
+	ReplayFraction float64
+
+	replay    []*Sample
+	replayPos int
+}
+
+// Len returns the epoch size.
+func (s *StreamSampleList) Len() int {
+	return s.EpochSize
+}
+
+// Swap does nothing, since samples are generated on
+// demand.
+func (s *StreamSampleList) Swap(i, j int) {
+}
+
+// Slice generates j-i samples.
+func (s *StreamSampleList) Slice(i, j int) anysgd.SampleList {
+	res := make(SampleList, j-i)
+	for k := range res {
+		res[k] = s.next()
+	}
+	return res
+}
+
+func (s *StreamSampleList) next() *Sample {
+	if len(s.replay) > 0 && rand.Float64() < s.ReplayFraction {
+		return s.replay[rand.Intn(len(s.replay))]
+	}
+	sample := s.Generators[rand.Intn(len(s.Generators))].Generate()
+	if s.ReplaySize > 0 {
+		if len(s.replay) < s.ReplaySize {
+			s.replay = append(s.replay, sample)
+		} else {
+			s.replay[s.replayPos] = sample
+			s.replayPos = (s.replayPos + 1) % s.ReplaySize
+		}
+	}
+	return sample
+}
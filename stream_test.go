@@ -0,0 +1,46 @@
+package algebrain
+
+import "testing"
+
+func TestStreamSampleListReplay(t *testing.T) {
+	gens := []Generator{Generators["EasyShift"], Generators["EasyEval"]}
+
+	s := &StreamSampleList{Generators: gens, EpochSize: 100, ReplaySize: 3}
+	if s.Len() != 100 {
+		t.Errorf("expected length 100 but got %d", s.Len())
+	}
+	samples := s.Slice(10, 15).(SampleList)
+	if len(samples) != 5 {
+		t.Fatalf("expected 5 samples but got %d", len(samples))
+	}
+	for i, sample := range samples {
+		for _, other := range samples[:i] {
+			if sample == other {
+				t.Fatal("sample was replayed with ReplayFraction 0")
+			}
+		}
+	}
+	expected := []*Sample{samples[3], samples[4], samples[2]}
+	if len(s.replay) != len(expected) {
+		t.Fatalf("expected %d replay samples but got %d", len(expected), len(s.replay))
+	}
+	for i, x := range expected {
+		if s.replay[i] != x {
+			t.Errorf("replay entry %d was not overwritten in order", i)
+		}
+	}
+
+	s = &StreamSampleList{Generators: gens, ReplaySize: 2, ReplayFraction: 1}
+	samples = s.Slice(0, 10).(SampleList)
+	for _, sample := range samples[1:] {
+		if sample != samples[0] {
+			t.Fatal("sample was generated with ReplayFraction 1")
+		}
+	}
+
+	s = &StreamSampleList{Generators: gens, ReplayFraction: 1}
+	samples = s.Slice(0, 10).(SampleList)
+	if samples[0] == samples[1] || len(s.replay) != 0 {
+		t.Error("replay buffer was used with ReplaySize 0")
+	}
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"time"
+
+	"github.com/unixpickle/algebrain"
+)
+
+// trainState is the progress of a training run, which is
+// saved next to every checkpoint so that training can be
+// resumed.
+type trainState struct {
+	Iter int
+
+	// BestAccuracy is the highest validation accuracy seen
+	// so far, or -1 if validation has not been run.
+	BestAccuracy float64
+
+	// Checkpoints is the number of periodic checkpoints
+	// which have been saved.
+	Checkpoints int
+
+	// CurriculumTier is the current tier of the curriculum,
+	// if one is used.
+	CurriculumTier int
+}
+
+func newTrainState() *trainState {
+	return &trainState{BestAccuracy: -1}
+}
+
+// loadTrainState loads the state saved with a model file.
+// If there is no saved state, a new one is returned.
+func loadTrainState(modelPath string) (*trainState, error) {
+	data, err := ioutil.ReadFile(statePath(modelPath))
+	if os.IsNotExist(err) {
+		return newTrainState(), nil
+	} else if err != nil {
+		return nil, err
+	}
+	res := newTrainState()
+	if err := json.Unmarshal(data, res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func statePath(modelPath string) string {
+	return modelPath + ".state"
+}
+
+// A checkpointer saves models during training.
+type checkpointer struct {
+	OutFile  string
+	BestFile string
+
+	// Iters and Interval specify how often to save periodic
+	// checkpoints. Either may be 0 to disable it.
+	Iters    int
+	Interval time.Duration
+
+	// Rotate is the number of periodic checkpoint files to
+	// cycle through.
+	Rotate int
+
+	lastSave time.Time
+}
+
+// Latest returns the newest of OutFile and the periodic
+// checkpoints, so that training can resume after a crash.
+// If none of them exist, OutFile is returned.
+func (c *checkpointer) Latest() string {
+	res := c.OutFile
+	var resTime time.Time
+	if info, err := os.Stat(c.OutFile); err == nil {
+		resTime = info.ModTime()
+	}
+	for i := 0; i < c.Rotate; i++ {
+		path := c.checkpointPath(i)
+		if info, err := os.Stat(path); err == nil && info.ModTime().After(resTime) {
+			res = path
+			resTime = info.ModTime()
+		}
+	}
+	return res
+}
+
+// Save saves the model and state to path.
+func (c *checkpointer) Save(path string, model algebrain.Model, state *trainState) error {
+	if err := algebrain.SaveAtomic(path, model); err != nil {
+		return err
+	}
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	return algebrain.WriteFileAtomic(statePath(path), data)
+}
+
+// Step saves a periodic checkpoint if one is due.
+// It returns the path of the checkpoint, or "" if none
+// was saved.
+func (c *checkpointer) Step(model algebrain.Model, state *trainState) (string, error) {
+	if c.lastSave.IsZero() {
+		c.lastSave = time.Now()
+	}
+	due := (c.Iters > 0 && state.Iter > 0 && state.Iter%c.Iters == 0) ||
+		(c.Interval > 0 && time.Since(c.lastSave) >= c.Interval)
+	if !due {
+		return "", nil
+	}
+	c.lastSave = time.Now()
+	path := c.checkpointPath(state.Checkpoints % c.Rotate)
+	state.Checkpoints++
+	return path, c.Save(path, model, state)
+}
+
+// Validated saves the model as the best model if its
+// validation accuracy is the highest so far.
+func (c *checkpointer) Validated(model algebrain.Model, state *trainState,
+	accuracy float64) (bool, error) {
+	if accuracy <= state.BestAccuracy {
+		return false, nil
+	}
+	state.BestAccuracy = accuracy
+	return true, c.Save(c.BestFile, model, state)
+}
+
+func (c *checkpointer) checkpointPath(idx int) string {
+	return fmt.Sprintf("%s.ckpt%d", c.OutFile, idx)
+}
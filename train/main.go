@@ -8,7 +8,6 @@ import (
 	"time"
 
 	"github.com/unixpickle/algebrain"
-	"github.com/unixpickle/algebrain/mathexpr"
 	"github.com/unixpickle/anynet/anysgd"
 	"github.com/unixpickle/anyvec/anyvec32"
 	"github.com/unixpickle/essentials"
@@ -16,129 +15,367 @@ import (
 	"github.com/unixpickle/serializer"
 )
 
-var Generators = map[string]algebrain.Generator{
-	"EasyShift": &algebrain.ShiftGenerator{
-		Generator: &mathexpr.Generator{
-			NoReals:  true,
-			VarNames: []string{"x"},
-		},
-		MaxDepth: 1,
-	},
-	"EasyScale": &algebrain.ScaleGenerator{
-		Generator: &mathexpr.Generator{
-			NoReals:  true,
-			VarNames: []string{"x"},
-		},
-		MaxDepth: 1,
-	},
-	"EasyEval": &algebrain.EvalGenerator{
-		Generator: &mathexpr.Generator{
-			NoReals: true,
-		},
-		MaxDepth: 1,
-		AllInts:  true,
-	},
-	"MediumShift": &algebrain.ShiftGenerator{
-		Generator: &mathexpr.Generator{
-			NoReals:  true,
-			VarNames: []string{"x"},
-		},
-		MaxDepth: 3,
-	},
-	"MediumScale": &algebrain.ScaleGenerator{
-		Generator: &mathexpr.Generator{
-			NoReals:  true,
-			VarNames: []string{"x"},
-		},
-		MaxDepth: 3,
-	},
-	"MediumEval": &algebrain.EvalGenerator{
-		Generator: &mathexpr.Generator{
-			NoReals: true,
-			Stddev:  80,
-		},
-		MaxDepth: 3,
-		AllInts:  true,
-	},
-	"HardShift": &algebrain.ShiftGenerator{
-		Generator: &mathexpr.Generator{
-			NoReals:  true,
-			VarNames: []string{"x", "y", "z"},
-		},
-		MaxDepth: 5,
-	},
-	"HardScale": &algebrain.ScaleGenerator{
-		Generator: &mathexpr.Generator{
-			NoReals:  true,
-			VarNames: []string{"x", "y", "z"},
-		},
-		MaxDepth: 5,
-	},
-}
-
 func main() {
 	var genNames string
-	var stepSize float64
+	schedConfig := &algebrain.ScheduleConfig{}
 	var batchSize int
 	var outFile string
 	var samplesPerGen int
+	var modelType string
+	var valSamplesPerGen int
+	var valSeed int64
+	var valInterval int
+	var ckptIters int
+	var ckptMinutes float64
+	var ckptRotate int
+	var bestFile string
+	var stream bool
+	var replaySize int
+	var replayFrac float64
+	var curriculumStr string
+	var mixtureStr string
+	var dataFile string
+	var valDataFile string
+	netConfig := algebrain.DefaultNetworkConfig()
+	tfConfig := algebrain.DefaultTransformerConfig()
 	flag.StringVar(&genNames, "generators",
-		"EasyShift,MediumShift,EasyScale,MediumScale,EasyEval,MediumEval,HardShift,HardScale",
+		strings.Join(algebrain.DefaultGeneratorNames, ","),
 		"comma-separated generator list")
-	flag.Float64Var(&stepSize, "step", 0.001, "SGD step size")
+	flag.Float64Var(&schedConfig.Rate, "step", 0.001, "SGD step size")
+	flag.StringVar(&schedConfig.Kind, "schedule", "const",
+		"step size schedule: const, step, cosine, or invsqrt")
+	flag.IntVar(&schedConfig.Warmup, "warmup", 0, "linear warmup iterations")
+	flag.IntVar(&schedConfig.Interval, "decayinterval", 10000,
+		"iterations between decays for -schedule step")
+	flag.Float64Var(&schedConfig.Factor, "decayfactor", 0.5,
+		"decay factor for -schedule step")
+	flag.IntVar(&schedConfig.Iters, "cosineiters", 100000,
+		"annealing iterations for -schedule cosine")
+	flag.Float64Var(&schedConfig.FinalRate, "finalstep", 0,
+		"final step size for -schedule cosine")
+	flag.IntVar(&schedConfig.Offset, "invsqrtoffset", 1000,
+		"iterations before decay for -schedule invsqrt")
 	flag.IntVar(&batchSize, "batch", 8, "SGD batch size")
-	flag.StringVar(&outFile, "file", "out_net", "output/input network file")
-	flag.IntVar(&samplesPerGen, "samples", 10000, "samples per generator")
+	flag.StringVar(&outFile, "file", "out_net",
+		"output/input network file (resumes from a newer <file>.ckptN)")
+	flag.IntVar(&samplesPerGen, "samples", 10000,
+		"samples per generator (per epoch with -stream)")
+	flag.IntVar(&valSamplesPerGen, "valsamples", 100,
+		"validation samples per generator")
+	flag.Int64Var(&valSeed, "valseed", 321, "random seed for validation samples")
+	flag.IntVar(&valInterval, "valinterval", 100,
+		"iterations between validation runs (0 to disable)")
+	flag.IntVar(&ckptIters, "ckptiters", 1000,
+		"iterations between checkpoints (0 to disable)")
+	flag.Float64Var(&ckptMinutes, "ckptmins", 0,
+		"minutes between checkpoints (0 to disable)")
+	flag.IntVar(&ckptRotate, "ckptrotate", 3, "number of checkpoint files to rotate")
+	flag.StringVar(&bestFile, "best", "", "best model file (default: <file>.best)")
+	flag.BoolVar(&stream, "stream", false,
+		"generate fresh samples for every batch")
+	flag.IntVar(&replaySize, "replay", 0, "replay buffer size for -stream")
+	flag.Float64Var(&replayFrac, "replayfrac", 0.5,
+		"fraction of -stream samples drawn from the replay buffer")
+	flag.StringVar(&curriculumStr, "curriculum", "",
+		"curriculum tiers like \"EasyShift,EasyScale:0.9;HardShift\", or \"default\"")
+	flag.StringVar(&mixtureStr, "mixture", "",
+		"weighted training generators like \"EasyShift:1,HardShift:3\"")
+	flag.StringVar(&dataFile, "data", "", "JSONL training samples (instead of generating)")
+	flag.StringVar(&valDataFile, "valdata", "",
+		"JSONL validation samples (instead of generating)")
+	flag.StringVar(&modelType, "model", "rnn",
+		"model type: rnn or transformer (new models only)")
+	flag.StringVar(&netConfig.EncoderCell, "enccell", netConfig.EncoderCell,
+		"encoder cell: lstm, gru, or rnn (new networks only)")
+	flag.StringVar(&netConfig.DecoderCell, "deccell", netConfig.DecoderCell,
+		"decoder cell: lstm, gru, or rnn (new networks only)")
+	flag.IntVar(&netConfig.EncoderLayers, "enclayers", netConfig.EncoderLayers,
+		"encoder layers (new networks only)")
+	flag.IntVar(&netConfig.EncoderHidden, "enchidden", netConfig.EncoderHidden,
+		"encoder hidden size (new networks only)")
+	flag.IntVar(&netConfig.EncodedSize, "encoded", netConfig.EncodedSize,
+		"encoded vector size (new networks only)")
+	flag.IntVar(&netConfig.DecoderLayers, "declayers", netConfig.DecoderLayers,
+		"decoder layers (new networks only)")
+	flag.IntVar(&netConfig.DecoderHidden, "dechidden", netConfig.DecoderHidden,
+		"decoder hidden size (new networks only)")
+	flag.IntVar(&netConfig.QuerySize, "querysize", netConfig.QuerySize,
+		"decoder output size (new networks only)")
+	flag.IntVar(&netConfig.AttentionSize, "attention", netConfig.AttentionSize,
+		"attention hidden size (new networks only)")
+	flag.Float64Var(&netConfig.InScale, "inscale", netConfig.InScale,
+		"input weight scale (new networks only)")
+	flag.BoolVar(&netConfig.Copy, "copy", netConfig.Copy,
+		"copy characters from the query (new networks only)")
+	flag.IntVar(&tfConfig.ModelSize, "tfsize", tfConfig.ModelSize,
+		"transformer model size (new transformers only)")
+	flag.IntVar(&tfConfig.Heads, "tfheads", tfConfig.Heads,
+		"transformer attention heads (new transformers only)")
+	flag.IntVar(&tfConfig.FeedForward, "tfhidden", tfConfig.FeedForward,
+		"transformer feed-forward size (new transformers only)")
+	flag.IntVar(&tfConfig.EncoderLayers, "tfenclayers", tfConfig.EncoderLayers,
+		"transformer encoder layers (new transformers only)")
+	flag.IntVar(&tfConfig.DecoderLayers, "tfdeclayers", tfConfig.DecoderLayers,
+		"transformer decoder layers (new transformers only)")
 	flag.Parse()
 
-	log.Println("Creating samples...")
-	training := generateSamples(genNames, samplesPerGen)
+	if batchSize < 1 {
+		essentials.Die("Batch size must be at least 1.")
+	}
+	if ckptRotate < 1 {
+		essentials.Die("Checkpoint rotation must be at least 1.")
+	}
+	if err := netConfig.Validate(); err != nil {
+		essentials.Die(err)
+	}
+	if err := tfConfig.Validate(); err != nil {
+		essentials.Die(err)
+	}
+	if bestFile == "" {
+		bestFile = outFile + ".best"
+	}
+
+	schedule, err := schedConfig.Schedule()
+	if err != nil {
+		essentials.Die(err)
+	}
+	rater := &algebrain.ScheduledRater{Schedule: schedule}
+
+	var curriculum *algebrain.Curriculum
+	if curriculumStr == "default" {
+		curriculum = algebrain.DefaultCurriculum()
+	} else if curriculumStr != "" {
+		curriculum, err = algebrain.ParseCurriculum(curriculumStr)
+		if err != nil {
+			essentials.Die(err)
+		}
+	}
+
+	if curriculum != nil && valInterval <= 0 {
+		essentials.Die("Cannot use -curriculum without validation (-valinterval).")
+	}
+
+	var mixture *algebrain.MixtureGenerator
+	if mixtureStr != "" {
+		if curriculum != nil {
+			essentials.Die("Cannot use -mixture with -curriculum.")
+		}
+		mixture, err = algebrain.ParseMixture(mixtureStr)
+		if err != nil {
+			essentials.Die(err)
+		}
+	}
+
+	if dataFile != "" && (stream || mixture != nil) {
+		essentials.Die("Cannot use -data with -stream or -mixture.")
+	}
+	var data, validation algebrain.SampleList
+	if dataFile != "" {
+		data = loadSamples(dataFile)
+	}
+	if valDataFile != "" {
+		validation = loadSamples(valDataFile)
+	} else {
+		log.Println("Creating validation samples...")
+		validation = generateSamples(namedGenerators(strings.Split(genNames, ",")),
+			valSamplesPerGen, valSeed)
+	}
 
 	rand.Seed(time.Now().UnixNano())
 
-	var net *algebrain.Network
-	if err := serializer.LoadAny(outFile, &net); err != nil {
-		log.Println("Creating new RNN block...")
-		net = algebrain.NewNetwork(anyvec32.CurrentCreator())
+	ckpt := &checkpointer{
+		OutFile:  outFile,
+		BestFile: bestFile,
+		Iters:    ckptIters,
+		Interval: time.Duration(ckptMinutes * float64(time.Minute)),
+		Rotate:   ckptRotate,
+	}
+
+	// Resume from the newest checkpoint in case the last run
+	// crashed before saving outFile.
+	inFile := ckpt.Latest()
+
+	var model algebrain.Model
+	state := newTrainState()
+	if err := serializer.LoadAny(inFile, &model); err != nil {
+		switch modelType {
+		case "rnn":
+			log.Println("Creating new RNN block...")
+			model = algebrain.NewNetworkConfig(anyvec32.CurrentCreator(), netConfig)
+		case "transformer":
+			log.Println("Creating new transformer...")
+			model = algebrain.NewTransformerConfig(anyvec32.CurrentCreator(), tfConfig)
+		default:
+			essentials.Die("Unknown model type:", modelType)
+		}
 	} else {
-		log.Println("Loaded existing RNN block.")
-	}
-
-	log.Println("Training...")
-	trainer := &algebrain.Trainer{Network: net}
-	var iter int
-	sgd := &anysgd.SGD{
-		Fetcher:     trainer,
-		Gradienter:  trainer,
-		Transformer: &anysgd.Adam{},
-		Samples:     training,
-		Rater:       anysgd.ConstRater(stepSize),
-		BatchSize:   batchSize,
-		StatusFunc: func(b anysgd.Batch) {
-			log.Printf("iter %d: cost=%v", iter, trainer.LastCost)
-			iter++
-		},
-	}
-	sgd.Run(rip.NewRIP().Chan())
-
-	if err := serializer.SaveAny(outFile, net); err != nil {
-		essentials.Die("Failed to save block:", err)
+		log.Println("Loaded existing model from", inFile)
+		state, err = loadTrainState(inFile)
+		if err != nil {
+			essentials.Die("Failed to load training state:", err)
+		}
+	}
+	if curriculum != nil {
+		if err := curriculum.SetTier(state.CurriculumTier); err != nil {
+			essentials.Die(err)
+		}
 	}
-}
 
-func generateSamples(genNames string, samplesPer int) algebrain.SampleList {
-	// Ensure that we get the same samples every time.
-	rand.Seed(123)
+	trainer := &algebrain.Trainer{Model: model}
+	interrupt := rip.NewRIP().Chan()
+	for {
+		trainNames := strings.Split(genNames, ",")
+		var tierValidation algebrain.SampleList
+		if curriculum != nil {
+			log.Printf("Using curriculum tier %d of %d.", curriculum.Tier+1,
+				len(curriculum.Tiers))
+			trainNames = curriculum.Generators()
+			if valDataFile != "" {
+				tierValidation = validation.Filter(curriculum.Current().Generators)
+			} else {
+				tierValidation = generateSamples(
+					namedGenerators(curriculum.Current().Generators), valSamplesPerGen,
+					valSeed)
+			}
+		}
 
-	names := strings.Split(genNames, ",")
-	gens := make([]algebrain.Generator, len(names))
-	for i, x := range names {
-		if gen, ok := Generators[x]; ok {
-			gens[i] = gen
+		log.Println("Creating samples...")
+		gens := namedGenerators(trainNames)
+		epochSize := samplesPerGen * len(gens)
+		if mixture != nil {
+			gens = []algebrain.Generator{mixture}
+			epochSize = samplesPerGen * len(mixture.Components)
+		}
+		var training anysgd.SampleList
+		if stream {
+			training = &algebrain.StreamSampleList{
+				Generators:     gens,
+				EpochSize:      epochSize,
+				ReplaySize:     replaySize,
+				ReplayFraction: replayFrac,
+			}
+		} else if data != nil {
+			training = data
+			if curriculum != nil {
+				training = data.Filter(trainNames)
+			}
 		} else {
-			essentials.Die("Unknown generator:", x)
+			training = generateSamples(gens, epochSize/len(gens), 123)
+		}
+		rand.Seed(time.Now().UnixNano())
+
+		// Training stops on an interrupt, and restarts with new
+		// samples whenever the curriculum moves to a new tier.
+		done := make(chan struct{})
+		var stopped, promoted bool
+		stop := func() {
+			if !stopped {
+				stopped = true
+				close(done)
+			}
+		}
+
+		log.Println("Training...")
+		sgd := &anysgd.SGD{
+			Fetcher:     trainer,
+			Gradienter:  trainer,
+			Transformer: &anysgd.Adam{},
+			Samples:     training,
+			Rater:       rater,
+			BatchSize:   batchSize,
+			StatusFunc: func(b anysgd.Batch) {
+				// The schedule position is the iteration count, which
+				// is saved with every checkpoint.
+				rater.Iter = state.Iter
+				rate := schedule.Rate(state.Iter)
+				if closed(interrupt) {
+					stop()
+				}
+				if valInterval > 0 && state.Iter%valInterval == 0 && !promoted {
+					validate(trainer, validation, batchSize, ckpt, state, rate)
+					if curriculum != nil && !curriculum.Done() {
+						val, err := trainer.Validate(tierValidation, batchSize)
+						if err != nil {
+							essentials.Die("Failed to validate:", err)
+						}
+						log.Printf("iter %d: tier_acc=%.2f%%", state.Iter, 100*val.Accuracy)
+						if curriculum.Update(val.Accuracy) {
+							state.CurriculumTier = curriculum.Tier
+							promoted = true
+							stop()
+						}
+					}
+				} else {
+					log.Printf("iter %d: cost=%v rate=%g", state.Iter, trainer.LastCost, rate)
+				}
+				if path, err := ckpt.Step(model, state); err != nil {
+					essentials.Die("Failed to save checkpoint:", err)
+				} else if path != "" {
+					log.Println("Saved checkpoint to", path)
+				}
+				state.Iter++
+			},
 		}
+		if err := sgd.Run(done); err != nil {
+			essentials.Die("Training failed:", err)
+		}
+		if closed(interrupt) {
+			break
+		}
+	}
+
+	if err := ckpt.Save(outFile, model, state); err != nil {
+		essentials.Die("Failed to save block:", err)
+	}
+}
+
+// validate evaluates the model on the validation set and
+// saves it if it is the best model so far.
+func validate(trainer *algebrain.Trainer, validation algebrain.SampleList,
+	batchSize int, ckpt *checkpointer, state *trainState, rate float64) {
+	val, err := trainer.Validate(validation, batchSize)
+	if err != nil {
+		essentials.Die("Failed to validate:", err)
+	}
+	log.Printf("iter %d: cost=%v rate=%g val_cost=%f val_acc=%.2f%%", state.Iter,
+		trainer.LastCost, rate, val.Cost, 100*val.Accuracy)
+	if best, err := ckpt.Validated(trainer.Model, state, val.Accuracy); err != nil {
+		essentials.Die("Failed to save best model:", err)
+	} else if best {
+		log.Println("Saved best model to", ckpt.BestFile)
+	}
+}
+
+func closed(ch <-chan struct{}) bool {
+	select {
+	case <-ch:
+		return true
+	default:
+		return false
+	}
+}
+
+func loadSamples(path string) algebrain.SampleList {
+	samples, err := algebrain.LoadSamples(path)
+	if err != nil {
+		essentials.Die(err)
 	}
+	return samples
+}
+
+func namedGenerators(names []string) []algebrain.Generator {
+	gens, err := algebrain.NamedGenerators(names)
+	if err != nil {
+		essentials.Die(err)
+	}
+	return gens
+}
+
+func generateSamples(gens []algebrain.Generator, samplesPer int,
+	seed int64) algebrain.SampleList {
+	// Ensure that we get the same samples every time.
+	rand.Seed(seed)
+
 	var training algebrain.SampleList
 	for _, g := range gens {
 		for i := 0; i < samplesPer; i++ {
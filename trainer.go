@@ -4,7 +4,6 @@ import (
 	"github.com/unixpickle/anydiff"
 	"github.com/unixpickle/anydiff/anyseq"
 	"github.com/unixpickle/anynet"
-	"github.com/unixpickle/anynet/anyrnn"
 	"github.com/unixpickle/anynet/anys2s"
 	"github.com/unixpickle/anynet/anysgd"
 	"github.com/unixpickle/anyvec"
@@ -35,27 +34,37 @@ type Batch struct {
 	DecOut anyseq.Seq
 }
 
-// A Trainer computes costs and gradients for a Network.
+// A Trainer computes costs and gradients for a Model.
 type Trainer struct {
-	Network *Network
+	Model Model
 
 	// LastCost is set by every call to Gradient.
 	LastCost anyvec.Numeric
 }
 
 // Fetch creates a *Batch from a SampleList.
+//
+// If a query or response contains an unsupported
+// character, an *InvalidRuneError is returned.
 func (t *Trainer) Fetch(s anysgd.SampleList) (anysgd.Batch, error) {
 	var encIn, decIn, decOut [][]anyvec.Vector
 	for i := 0; i < s.Len(); i++ {
 		sample := s.(SampleList)[i]
-		encIn = append(encIn, sample.InputSequence())
+		inSeq, err := sample.InputSequence()
+		if err != nil {
+			return nil, err
+		}
+		if err := ValidateString(sample.Response); err != nil {
+			return nil, err
+		}
+		encIn = append(encIn, inSeq)
 		decIn = append(decIn, sample.DecoderInSequence())
 		decOut = append(decOut, sample.DecoderOutSequence())
 	}
 	return &Batch{
-		EncIn:  anyseq.ConstSeqList(t.Network.creator(), encIn),
-		DecIn:  anyseq.ConstSeqList(t.Network.creator(), decIn),
-		DecOut: anyseq.ConstSeqList(t.Network.creator(), decOut),
+		EncIn:  anyseq.ConstSeqList(modelCreator(t.Model), encIn),
+		DecIn:  anyseq.ConstSeqList(modelCreator(t.Model), decIn),
+		DecOut: anyseq.ConstSeqList(modelCreator(t.Model), decOut),
 	}, nil
 }
 
@@ -77,15 +86,10 @@ func (t *Trainer) Gradient(b anysgd.Batch) anydiff.Grad {
 func (t *Trainer) tempTrainer(b anysgd.Batch) (*anys2s.Trainer, *anys2s.Batch) {
 	return &anys2s.Trainer{
 			Func: func(s anyseq.Seq) anyseq.Seq {
-				enc := t.Network.Encoder.Apply(s)
-				return anyseq.Pool(enc, func(enc anyseq.Seq) anyseq.Seq {
-					block := t.Network.Align.Block(enc)
-					return anyseq.Map(anyrnn.Map(b.(*Batch).DecIn, block),
-						t.Network.Output.Apply)
-				})
+				return t.Model.LogProbs(s, b.(*Batch).DecIn)
 			},
 			Cost:    anynet.DotCost{},
-			Params:  t.Network.Parameters(),
+			Params:  t.Model.Parameters(),
 			Average: true,
 		}, &anys2s.Batch{
 			Inputs:  b.(*Batch).EncIn,
@@ -0,0 +1,413 @@
+package algebrain
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/unixpickle/anydiff"
+	"github.com/unixpickle/anydiff/anyseq"
+	"github.com/unixpickle/anynet"
+	"github.com/unixpickle/anyvec"
+	"github.com/unixpickle/essentials"
+	"github.com/unixpickle/serializer"
+)
+
+func init() {
+	var t Transformer
+	serializer.RegisterTypedDeserializer(t.SerializerType(), DeserializeTransformer)
+}
+
+// A TransformerConfig specifies the architecture of a
+// Transformer.
+type TransformerConfig struct {
+	// ModelSize is the size of the vectors passed between
+	// layers.
+	ModelSize int
+
+	// Heads is the number of attention heads per layer.
+	// It must divide ModelSize.
+	Heads int
+
+	// FeedForward is the hidden size of the feed-forward
+	// network in each layer.
+	FeedForward int
+
+	EncoderLayers int
+	DecoderLayers int
+}
+
+// DefaultTransformerConfig creates the configuration used
+// by NewTransformer.
+func DefaultTransformerConfig() *TransformerConfig {
+	return &TransformerConfig{
+		ModelSize:     0x80,
+		Heads:         4,
+		FeedForward:   0x200,
+		EncoderLayers: 2,
+		DecoderLayers: 2,
+	}
+}
+
+// Validate checks that the configuration describes a
+// Transformer which can be created.
+func (t *TransformerConfig) Validate() error {
+	if t.ModelSize < 1 || t.Heads < 1 || t.FeedForward < 1 {
+		return fmt.Errorf("transformer config: invalid sizes (model %d, heads %d, "+
+			"feed-forward %d)", t.ModelSize, t.Heads, t.FeedForward)
+	}
+	if t.ModelSize%t.Heads != 0 {
+		return fmt.Errorf("transformer config: %d heads do not divide model size %d",
+			t.Heads, t.ModelSize)
+	}
+	if t.EncoderLayers < 1 || t.DecoderLayers < 1 {
+		return fmt.Errorf("transformer config: need at least one encoder and decoder "+
+			"layer (got %d and %d)", t.EncoderLayers, t.DecoderLayers)
+	}
+	return nil
+}
+
+// A TransformerLayer is one layer of the encoder or
+// decoder in a Transformer.
+//
+// Each sub-layer normalizes its input and adds its output
+// back to its input.
+type TransformerLayer struct {
+	// Causal restricts self-attention to previous rows.
+	// It is set for decoder layers.
+	// Since it is implied by a layer's position in the
+	// model, it is not serialized.
+	Causal bool
+
+	SelfAttention *MultiHeadAttention
+	SelfNorm      *anynet.Affine
+
+	// CrossAttention attends to the encoder's output.
+	// It is nil for encoder layers.
+	CrossAttention *MultiHeadAttention
+	CrossNorm      *anynet.Affine
+
+	FeedForward     anynet.Net
+	FeedForwardNorm *anynet.Affine
+}
+
+// Apply applies the layer to a sequence of n rows.
+//
+// For layers with CrossAttention, mem is the output of
+// the encoder.
+func (t *TransformerLayer) Apply(x anydiff.Res, n int, mem anydiff.Res,
+	memLen int) anydiff.Res {
+	d := t.SelfAttention.ModelSize
+	h := layerNorm(x, n, d, t.SelfNorm)
+	x = anydiff.Add(x, t.SelfAttention.Apply(h, n, h, n, t.Causal))
+	if t.CrossAttention != nil {
+		h = layerNorm(x, n, d, t.CrossNorm)
+		x = anydiff.Add(x, t.CrossAttention.Apply(h, n, mem, memLen, false))
+	}
+	h = layerNorm(x, n, d, t.FeedForwardNorm)
+	return anydiff.Add(x, t.FeedForward.Apply(h, n))
+}
+
+// Parameters returns the parameters of the layer.
+func (t *TransformerLayer) Parameters() []*anydiff.Var {
+	var res []*anydiff.Var
+	for _, p := range t.components() {
+		res = append(res, p.(anynet.Parameterizer).Parameters()...)
+	}
+	return res
+}
+
+func (t *TransformerLayer) components() []serializer.Serializer {
+	res := []serializer.Serializer{t.SelfAttention, t.SelfNorm}
+	if t.CrossAttention != nil {
+		res = append(res, t.CrossAttention, t.CrossNorm)
+	}
+	return append(res, t.FeedForward, t.FeedForwardNorm)
+}
+
+// A Transformer is a self-attention encoder-decoder model
+// which can be used in place of a Network.
+type Transformer struct {
+	// Config is the architecture of the model.
+	Config *TransformerConfig
+
+	// Embedding maps one-hot characters to vectors for both
+	// the encoder and the decoder.
+	Embedding *anynet.FC
+
+	Encoder     []*TransformerLayer
+	EncoderNorm *anynet.Affine
+	Decoder     []*TransformerLayer
+	DecoderNorm *anynet.Affine
+
+	Output anynet.Net
+}
+
+// DeserializeTransformer deserializes a Transformer.
+func DeserializeTransformer(d []byte) (*Transformer, error) {
+	var cfgData, partsData serializer.Bytes
+	if err := serializer.DeserializeAny(d, &cfgData, &partsData); err != nil {
+		return nil, essentials.AddCtx("deserialize Transformer", err)
+	}
+	res := &Transformer{}
+	if err := json.Unmarshal([]byte(cfgData), &res.Config); err != nil {
+		return nil, essentials.AddCtx("deserialize Transformer", err)
+	}
+	parts, err := serializer.DeserializeSlice([]byte(partsData))
+	if err != nil {
+		return nil, essentials.AddCtx("deserialize Transformer", err)
+	}
+	if err := res.setParts(parts); err != nil {
+		return nil, essentials.AddCtx("deserialize Transformer", err)
+	}
+	return res, nil
+}
+
+// NewTransformer creates a randomly-initialized
+// Transformer with the default configuration.
+func NewTransformer(c anyvec.Creator) *Transformer {
+	return NewTransformerConfig(c, DefaultTransformerConfig())
+}
+
+// NewTransformerConfig creates a randomly-initialized
+// Transformer with the given architecture.
+//
+// It panics if the configuration is invalid, so untrusted
+// configurations should be checked with cfg.Validate.
+func NewTransformerConfig(c anyvec.Creator, cfg *TransformerConfig) *Transformer {
+	if err := cfg.Validate(); err != nil {
+		panic(err)
+	}
+	d := cfg.ModelSize
+	newLayer := func(decoder bool) *TransformerLayer {
+		res := &TransformerLayer{
+			Causal:        decoder,
+			SelfAttention: NewMultiHeadAttention(c, cfg.Heads, d),
+			SelfNorm:      newLayerNormAffine(c, d),
+			FeedForward: anynet.Net{
+				anynet.NewFC(c, d, cfg.FeedForward),
+				anynet.ReLU,
+				anynet.NewFC(c, cfg.FeedForward, d),
+			},
+			FeedForwardNorm: newLayerNormAffine(c, d),
+		}
+		if decoder {
+			res.CrossAttention = NewMultiHeadAttention(c, cfg.Heads, d)
+			res.CrossNorm = newLayerNormAffine(c, d)
+		}
+		return res
+	}
+	cfgCopy := *cfg
+	res := &Transformer{
+		Config:      &cfgCopy,
+		Embedding:   anynet.NewFC(c, CharCount, d),
+		EncoderNorm: newLayerNormAffine(c, d),
+		DecoderNorm: newLayerNormAffine(c, d),
+		Output: anynet.Net{
+			anynet.NewFC(c, d, CharCount),
+			anynet.LogSoftmax,
+		},
+	}
+	for i := 0; i < cfg.EncoderLayers; i++ {
+		res.Encoder = append(res.Encoder, newLayer(false))
+	}
+	for i := 0; i < cfg.DecoderLayers; i++ {
+		res.Decoder = append(res.Decoder, newLayer(true))
+	}
+	return res
+}
+
+// Parameters gets the parameters of the model.
+func (t *Transformer) Parameters() []*anydiff.Var {
+	var res []*anydiff.Var
+	for _, p := range t.parts() {
+		res = append(res, p.(anynet.Parameterizer).Parameters()...)
+	}
+	return res
+}
+
+// SerializerType returns the unique ID used to serialize
+// a Transformer with the serializer package.
+func (t *Transformer) SerializerType() string {
+	return "github.com/unixpickle/algebrain.Transformer"
+}
+
+// Serialize attempts to serialize the Transformer.
+func (t *Transformer) Serialize() ([]byte, error) {
+	cfgData, err := json.Marshal(t.Config)
+	if err != nil {
+		return nil, err
+	}
+	partsData, err := serializer.SerializeSlice(t.parts())
+	if err != nil {
+		return nil, err
+	}
+	return serializer.SerializeAny(serializer.Bytes(cfgData), serializer.Bytes(partsData))
+}
+
+// Query runs a query against the Transformer.
+//
+// Decoding is greedy, and the decoder is re-applied to the
+// entire response so far for every output character, so
+// the cost grows quadratically with the response length.
+//
+// If the query contains an unsupported character, an
+// *InvalidRuneError is returned.
+func (t *Transformer) Query(q string) (string, error) {
+	sample := &Sample{Query: q}
+	inSeq, err := sample.InputSequence()
+	if err != nil {
+		return "", err
+	}
+	enc := t.encode(inSeq)
+
+	decIn := []anyvec.Vector{oneHotVector(Terminator)}
+	var res string
+	for len(res) < maxResponseLen {
+		out := t.decode(enc, len(inSeq), decIn).Output()
+		lastRow := out.Slice((len(decIn)-1)*CharCount, len(decIn)*CharCount)
+		next := rune(anyvec.MaxIndex(lastRow))
+		if next == Terminator {
+			break
+		}
+		res += string(next)
+		decIn = append(decIn, oneHotVector(next))
+	}
+	return res, nil
+}
+
+// QueryBatch runs each of the queries in turn with Query.
+//
+// Unlike Network.QueryBatch, the queries are not batched
+// together, so this is no faster than calling Query for
+// each query.
+func (t *Transformer) QueryBatch(qs []string) ([]string, error) {
+	res := make([]string, len(qs))
+	for i, q := range qs {
+		var err error
+		res[i], err = t.Query(q)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
+// LogProbs applies the model to a batch of queries while
+// feeding decIn to the decoder, producing the log
+// probabilities for each character of the responses.
+func (t *Transformer) LogProbs(encIn, decIn anyseq.Seq) anyseq.Seq {
+	encSeqs := splitSeq(encIn)
+	decSeqs := splitSeq(decIn)
+	outs := make([]anydiff.Res, len(decSeqs))
+	lens := make([]int, len(decSeqs))
+	for i, decSeq := range decSeqs {
+		enc := t.encode(encSeqs[i])
+		outs[i] = t.decode(enc, len(encSeqs[i]), decSeq)
+		lens[i] = len(decSeq)
+	}
+	return packSeqs(encIn.Creator(), outs, lens, CharCount)
+}
+
+func (t *Transformer) encode(in []anyvec.Vector) anydiff.Res {
+	x := t.embed(in)
+	for _, layer := range t.Encoder {
+		x = layer.Apply(x, len(in), nil, 0)
+	}
+	return layerNorm(x, len(in), t.Config.ModelSize, t.EncoderNorm)
+}
+
+func (t *Transformer) decode(enc anydiff.Res, encLen int, in []anyvec.Vector) anydiff.Res {
+	x := t.embed(in)
+	for _, layer := range t.Decoder {
+		x = layer.Apply(x, len(in), enc, encLen)
+	}
+	x = layerNorm(x, len(in), t.Config.ModelSize, t.DecoderNorm)
+	return t.Output.Apply(x, len(in))
+}
+
+func (t *Transformer) embed(in []anyvec.Vector) anydiff.Res {
+	c := t.Embedding.Weights.Vector.Creator()
+	x := t.Embedding.Apply(anydiff.NewConst(c.Concat(in...)), len(in))
+	pos := positionalEncoding(c, len(in), t.Config.ModelSize)
+	return anydiff.Add(x, anydiff.NewConst(pos))
+}
+
+// parts lists the components of the model in the order
+// they are serialized.
+func (t *Transformer) parts() []serializer.Serializer {
+	res := []serializer.Serializer{t.Embedding}
+	for _, layer := range t.Encoder {
+		res = append(res, layer.components()...)
+	}
+	res = append(res, t.EncoderNorm)
+	for _, layer := range t.Decoder {
+		res = append(res, layer.components()...)
+	}
+	return append(res, t.DecoderNorm, t.Output)
+}
+
+func (t *Transformer) setParts(parts []serializer.Serializer) error {
+	errBadParts := errors.New("unexpected model components")
+	next := func() serializer.Serializer {
+		if len(parts) == 0 {
+			return nil
+		}
+		res := parts[0]
+		parts = parts[1:]
+		return res
+	}
+	nextAttention := func() (*MultiHeadAttention, *anynet.Affine, bool) {
+		attention, ok1 := next().(*MultiHeadAttention)
+		norm, ok2 := next().(*anynet.Affine)
+		return attention, norm, ok1 && ok2
+	}
+	nextLayer := func(decoder bool) (*TransformerLayer, bool) {
+		res := &TransformerLayer{Causal: decoder}
+		var ok bool
+		if res.SelfAttention, res.SelfNorm, ok = nextAttention(); !ok {
+			return nil, false
+		}
+		if decoder {
+			if res.CrossAttention, res.CrossNorm, ok = nextAttention(); !ok {
+				return nil, false
+			}
+		}
+		var ok1, ok2 bool
+		res.FeedForward, ok1 = next().(anynet.Net)
+		res.FeedForwardNorm, ok2 = next().(*anynet.Affine)
+		return res, ok1 && ok2
+	}
+
+	var ok bool
+	if t.Embedding, ok = next().(*anynet.FC); !ok {
+		return errBadParts
+	}
+	for i := 0; i < t.Config.EncoderLayers; i++ {
+		layer, ok := nextLayer(false)
+		if !ok {
+			return errBadParts
+		}
+		t.Encoder = append(t.Encoder, layer)
+	}
+	if t.EncoderNorm, ok = next().(*anynet.Affine); !ok {
+		return errBadParts
+	}
+	for i := 0; i < t.Config.DecoderLayers; i++ {
+		layer, ok := nextLayer(true)
+		if !ok {
+			return errBadParts
+		}
+		t.Decoder = append(t.Decoder, layer)
+	}
+	if t.DecoderNorm, ok = next().(*anynet.Affine); !ok {
+		return errBadParts
+	}
+	if t.Output, ok = next().(anynet.Net); !ok {
+		return errBadParts
+	}
+	if len(parts) != 0 {
+		return errBadParts
+	}
+	return nil
+}
@@ -0,0 +1,25 @@
+package algebrain
+
+import "testing"
+
+func TestTransformerConfigValidate(t *testing.T) {
+	if err := DefaultTransformerConfig().Validate(); err != nil {
+		t.Fatal(err)
+	}
+	invalid := []func(c *TransformerConfig){
+		func(c *TransformerConfig) { c.Heads = 3 },
+		func(c *TransformerConfig) { c.Heads = 0 },
+		func(c *TransformerConfig) { c.Heads = -4 },
+		func(c *TransformerConfig) { c.ModelSize = 0 },
+		func(c *TransformerConfig) { c.FeedForward = 0 },
+		func(c *TransformerConfig) { c.EncoderLayers = 0 },
+		func(c *TransformerConfig) { c.DecoderLayers = -1 },
+	}
+	for i, f := range invalid {
+		c := DefaultTransformerConfig()
+		f(c)
+		if err := c.Validate(); err == nil {
+			t.Errorf("case %d: expected error for %+v", i, *c)
+		}
+	}
+}
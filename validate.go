@@ -0,0 +1,61 @@
+package algebrain
+
+import "fmt"
+
+// A Validation summarizes the performance of a Model on a
+// held-out SampleList.
+type Validation struct {
+	// Cost is the average cost per sample.
+	Cost float64
+
+	// Accuracy is the fraction of samples for which the
+	// greedy response exactly matched the expected one.
+	Accuracy float64
+}
+
+// Validate computes the average cost and the exact-match
+// accuracy of t.Model on the samples.
+//
+// Samples are processed batchSize at a time, so batchSize
+// must be at least 1.
+func (t *Trainer) Validate(samples SampleList, batchSize int) (*Validation, error) {
+	if batchSize < 1 {
+		return nil, fmt.Errorf("validate: invalid batch size %d", batchSize)
+	}
+	res := &Validation{}
+	if len(samples) == 0 {
+		return res, nil
+	}
+	var numCorrect int
+	for i := 0; i < len(samples); i += batchSize {
+		end := i + batchSize
+		if end > len(samples) {
+			end = len(samples)
+		}
+		batchSamples := samples[i:end]
+
+		batch, err := t.Fetch(batchSamples)
+		if err != nil {
+			return nil, err
+		}
+		cost := vectorFloats(t.TotalCost(batch).Output())[0]
+		res.Cost += cost * float64(len(batchSamples))
+
+		queries := make([]string, len(batchSamples))
+		for j, sample := range batchSamples {
+			queries[j] = sample.Query
+		}
+		responses, err := t.Model.QueryBatch(queries)
+		if err != nil {
+			return nil, err
+		}
+		for j, sample := range batchSamples {
+			if responses[j] == sample.Response {
+				numCorrect++
+			}
+		}
+	}
+	res.Cost /= float64(len(samples))
+	res.Accuracy = float64(numCorrect) / float64(len(samples))
+	return res, nil
+}
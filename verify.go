@@ -0,0 +1,206 @@
+package algebrain
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/unixpickle/algebrain/mathexpr"
+)
+
+// DefaultVerifierBeamWidth is the beam width used by a
+// Verifier which does not specify a beam width or a number
+// of samples.
+const DefaultVerifierBeamWidth = 5
+
+// ErrUnverifiable is returned when a query does not belong
+// to a task whose responses can be checked.
+var ErrUnverifiable = errors.New("query cannot be verified")
+
+// Verify checks a response against the query that
+// produced it.
+//
+// Shift and scale queries are checked by numerically
+// comparing the response to the transformed expression.
+// Evaluate queries are checked by evaluating the
+// expression in the query.
+// For other queries, ErrUnverifiable is returned.
+func (c *Checker) Verify(query, response string) (bool, error) {
+	if strings.HasPrefix(query, "evaluate ") {
+		return c.verifyEval(strings.TrimPrefix(query, "evaluate "), response)
+	}
+	for _, task := range []string{"shift", "scale"} {
+		if strings.HasPrefix(query, task+" ") {
+			expected, err := transformedExpr(task, strings.TrimPrefix(query, task+" "))
+			if err != nil {
+				return false, err
+			}
+			if strings.HasPrefix(response, ResultPrefix) {
+				return false, nil
+			}
+			actual, err := mathexpr.Parse(response)
+			if err != nil {
+				return false, nil
+			}
+			return c.NumericMatch(expected, actual), nil
+		}
+	}
+	return false, ErrUnverifiable
+}
+
+func (c *Checker) verifyEval(exprStr, response string) (bool, error) {
+	expr, err := mathexpr.Parse(exprStr)
+	if err != nil || !isArithmetic(expr) {
+		return false, ErrUnverifiable
+	}
+	if !strings.HasPrefix(response, ResultPrefix) {
+		return false, nil
+	}
+	actualExpr, err := ParseResponse(response)
+	if err != nil {
+		return false, nil
+	}
+	actual, err := mathexpr.Eval(actualExpr, nil)
+	if err != nil {
+		return false, nil
+	}
+
+	// The query does not say whether integer arithmetic was
+	// used, so either interpretation is accepted.
+	for _, allInts := range []bool{false, true} {
+		expected, err := evalRounded(expr, allInts)
+		if err != nil {
+			continue
+		}
+		scale := math.Max(1, math.Abs(expected))
+		if math.Abs(expected-actual) <= c.tolerance()*scale {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// transformedExpr computes the expected result of a query
+// like "x by 2 in x^2" for a shift or scale task.
+func transformedExpr(task, query string) (mathexpr.Node, error) {
+	byIdx := strings.Index(query, " by ")
+	inIdx := strings.Index(query, " in ")
+	if byIdx < 0 || inIdx < byIdx {
+		return nil, ErrUnverifiable
+	}
+	varName := query[:byIdx]
+	amount, err := mathexpr.Parse(query[byIdx+4 : inIdx])
+	if err != nil {
+		return nil, ErrUnverifiable
+	}
+	expr, err := mathexpr.Parse(query[inIdx+4:])
+	if err != nil {
+		return nil, ErrUnverifiable
+	}
+	if task == "shift" {
+		return (&ShiftGenerator{}).shiftNode(varName, amount, expr), nil
+	}
+	return (&ScaleGenerator{}).scaleNode(varName, amount, expr), nil
+}
+
+// evalRounded evaluates an expression the way an
+// EvalGenerator would, including the rounding of the
+// response.
+func evalRounded(n mathexpr.Node, allInts bool) (float64, error) {
+	val, err := evalArithmetic(n, allInts)
+	if err != nil {
+		return 0, err
+	}
+	if math.IsNaN(val) || math.IsInf(val, 0) {
+		return 0, &mathexpr.DomainError{Expr: n.String(), Msg: "result is not finite"}
+	}
+	prec := 2
+	if allInts {
+		prec = 0
+	}
+	return strconv.ParseFloat(strconv.FormatFloat(val, 'f', prec, 64), 64)
+}
+
+// isArithmetic checks that an expression only contains
+// numbers and arithmetic operators.
+func isArithmetic(n mathexpr.Node) bool {
+	switch n := n.(type) {
+	case mathexpr.RawNode:
+		_, err := strconv.ParseFloat(string(n), 64)
+		return err == nil
+	case *mathexpr.BinaryOp, *mathexpr.NegOp:
+		for _, child := range n.Children() {
+			if !isArithmetic(child) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// A Verifier produces several candidate responses to a
+// query and picks one which can be verified.
+type Verifier struct {
+	// Checker is used to verify candidates.
+	Checker Checker
+
+	// BeamWidth is the number of beam search candidates.
+	// If both BeamWidth and Samples are 0,
+	// DefaultVerifierBeamWidth is used.
+	BeamWidth int
+
+	// Samples is the number of sampled candidates, which
+	// are tried after the beam search candidates.
+	Samples int
+
+	// Sampler is used to produce sampled candidates.
+	// If nil, a default Sampler is used.
+	Sampler *Sampler
+}
+
+// QueryVerified runs a query and returns the first
+// candidate response which passes verification.
+//
+// If no candidate is verified, or if the query cannot be
+// verified, the most likely candidate is returned and the
+// flag is false.
+func (n *Network) QueryVerified(q string, v *Verifier) (string, bool, error) {
+	beamWidth := v.BeamWidth
+	if beamWidth == 0 && v.Samples == 0 {
+		beamWidth = DefaultVerifierBeamWidth
+	}
+	var candidates []*Candidate
+	if beamWidth > 0 {
+		beam, err := n.QueryBeam(q, beamWidth)
+		if err != nil {
+			return "", false, err
+		}
+		candidates = append(candidates, beam...)
+	}
+	sampler := v.Sampler
+	if sampler == nil {
+		sampler = &Sampler{}
+	}
+	for i := 0; i < v.Samples; i++ {
+		candidate, err := n.QuerySample(q, sampler)
+		if err != nil {
+			return "", false, err
+		}
+		candidates = append(candidates, candidate)
+	}
+	if len(candidates) == 0 {
+		return "", false, nil
+	}
+
+	for _, candidate := range candidates {
+		ok, err := v.Checker.Verify(q, candidate.Response)
+		if err == ErrUnverifiable {
+			break
+		} else if ok {
+			return candidate.Response, true, nil
+		}
+	}
+	return candidates[0].Response, false, nil
+}
@@ -0,0 +1,103 @@
+package algebrain
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/unixpickle/algebrain/mathexpr"
+)
+
+func TestCheckerVerify(t *testing.T) {
+	checker := &Checker{Rand: rand.New(rand.NewSource(1337))}
+	cases := []struct {
+		Query    string
+		Response string
+		Expected bool
+	}{
+		{"shift x by 2 in x^2", "(x-2)^2", true},
+		{"shift x by 2 in x^2", "x^2-4*x+4", true},
+		{"shift x by 2 in x^2", "(x+2)^2", false},
+		{"shift y by -1 in x+y", "x+(y--1)", true},
+		{"shift y by -1 in x+y", "x+y+1", true},
+		{"shift y by -1 in x+y", "x+y-1", false},
+		{"shift x by 2 in x^2", ResultPrefix + "(x-2)^2", false},
+		{"shift x by 2 in x^2", "(x-2", false},
+		{"scale x by 3 in x+1", "x*3+1", true},
+		{"scale x by 3 in x+1", "3*x+1", true},
+		{"scale x by 3 in x+1", "x+3", false},
+		{"scale z by 2 in sin(z)", "sin(2*z)", true},
+
+		{"evaluate 3*4-5", ResultPrefix + "7", true},
+		{"evaluate 3*4-5", ResultPrefix + "7.00", true},
+		{"evaluate 3*4-5", ResultPrefix + "8", false},
+		{"evaluate 3*4-5", "7", false},
+		{"evaluate 3*4-5", ResultPrefix + "seven", false},
+
+		// Integer and real arithmetic are both accepted.
+		{"evaluate 7/2", ResultPrefix + "3", true},
+		{"evaluate 7/2", ResultPrefix + "3.50", true},
+		{"evaluate 7/2", ResultPrefix + "4", false},
+		{"evaluate 2^-1", ResultPrefix + "0", true},
+		{"evaluate 2^-1", ResultPrefix + "0.50", true},
+		{"evaluate 2/3", ResultPrefix + "0.67", true},
+		{"evaluate 2/3", ResultPrefix + "0", true},
+		{"evaluate -7/2", ResultPrefix + "-3", true},
+		{"evaluate 7/(1/2)", ResultPrefix + "14", true},
+		{"evaluate 7/(1/2)", ResultPrefix + "0", false},
+		{"evaluate 1/0", ResultPrefix + "0", false},
+	}
+	for _, c := range cases {
+		actual, err := checker.Verify(c.Query, c.Response)
+		if err != nil {
+			t.Errorf("%q -> %q: %v", c.Query, c.Response, err)
+		} else if actual != c.Expected {
+			t.Errorf("%q -> %q: expected %v but got %v", c.Query, c.Response,
+				c.Expected, actual)
+		}
+	}
+}
+
+func TestCheckerVerifyUnverifiable(t *testing.T) {
+	checker := &Checker{Rand: rand.New(rand.NewSource(1337))}
+	queries := []string{
+		"factorize x^2+x",
+		"evaluate x+1",
+		"evaluate pi*2",
+		"evaluate (1+",
+		"shift x 2 in x^2",
+		"shift x in x^2 by 2",
+		"shift x by 2 in x^",
+		"scale x by * in x",
+	}
+	for _, q := range queries {
+		if _, err := checker.Verify(q, "x"); err != ErrUnverifiable {
+			t.Errorf("%q: expected ErrUnverifiable but got %v", q, err)
+		}
+	}
+}
+
+func TestCheckerVerifyEvalGenerator(t *testing.T) {
+	checker := &Checker{Rand: rand.New(rand.NewSource(1337))}
+	gens := []Generator{Generators["EasyEval"], Generators["MediumEval"]}
+	for _, allInts := range []bool{false, true} {
+		gens = append(gens, &EvalGenerator{
+			Generator: &mathexpr.Generator{NoReals: true},
+			MaxDepth:  3,
+			AllInts:   allInts,
+			UseDiv:    true,
+			UsePow:    true,
+		})
+	}
+	rand.Seed(1337)
+	for _, gen := range gens {
+		for i := 0; i < 100; i++ {
+			sample := gen.Generate()
+			ok, err := checker.Verify(sample.Query, sample.Response)
+			if err != nil {
+				t.Fatalf("%q: %v", sample.Query, err)
+			} else if !ok {
+				t.Errorf("%q: rejected %q", sample.Query, sample.Response)
+			}
+		}
+	}
+}